@@ -0,0 +1,188 @@
+// Combat resolution for SpaceJunk3000, based on the "Escape the Dark Sector"
+// dice-matching rules. Everything in this package is pure: callers pass in the
+// current state and a source of randomness, and get back a result to render.
+
+package combat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mode is the kind of combat being fought, which decides the damage an enemy
+// deals back when it survives a round.
+type Mode int
+
+const (
+	Ranged Mode = iota
+	Close
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Ranged:
+		return "Ranged"
+	case Close:
+		return "Close"
+	default:
+		return "Unknown"
+	}
+}
+
+// Stat is one of the three symbols that can appear on a crew die.
+type Stat string
+
+const (
+	Strength     Stat = "strength"
+	Dexterity    Stat = "dexterity"
+	Intelligence Stat = "intelligence"
+)
+
+// Face is a single parsed crew die face, e.g. "double strength".
+type Face struct {
+	Stat  Stat
+	Count int
+}
+
+func (f Face) String() string {
+	if f.Count > 1 {
+		return fmt.Sprintf("double %s", f.Stat)
+	}
+	return string(f.Stat)
+}
+
+// ParseFace turns a crew die face as stored on the player ("strength",
+// "double dexterity", ...) into a Face. Only the stat prefix is checked so
+// older saves with misspelled faces still roll correctly.
+func ParseFace(s string) (Face, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	count := 1
+	if strings.Contains(s, "double") {
+		count = 2
+		s = strings.TrimSpace(s[strings.LastIndex(s, "double")+len("double"):])
+	}
+
+	switch {
+	case strings.HasPrefix(s, "str"):
+		return Face{Stat: Strength, Count: count}, nil
+	case strings.HasPrefix(s, "dex"):
+		return Face{Stat: Dexterity, Count: count}, nil
+	case strings.HasPrefix(s, "int"):
+		return Face{Stat: Intelligence, Count: count}, nil
+	default:
+		return Face{}, fmt.Errorf("unknown crew die face %q", s)
+	}
+}
+
+// Requirements are the symbols still needed to defeat an enemy.
+type Requirements struct {
+	Strength     int
+	Dexterity    int
+	Intelligence int
+}
+
+// Met reports whether every requirement has been matched.
+func (r Requirements) Met() bool {
+	return r.Strength <= 0 && r.Dexterity <= 0 && r.Intelligence <= 0
+}
+
+// Total returns the number of symbols still required.
+func (r Requirements) Total() int {
+	return max(r.Strength, 0) + max(r.Dexterity, 0) + max(r.Intelligence, 0)
+}
+
+// get returns a pointer to the requirement for the given stat.
+func (r *Requirements) get(s Stat) *int {
+	switch s {
+	case Strength:
+		return &r.Strength
+	case Dexterity:
+		return &r.Dexterity
+	default:
+		return &r.Intelligence
+	}
+}
+
+// CloseDice returns how many crew dice are rolled in a round of close
+// combat: one for every three points of Strength and Dexterity combined, and
+// one more for fighting with a blade. Fighting unarmed always rolls at least
+// one die.
+func CloseDice(strength, dexterity int, armed bool) int {
+	dice := (strength + dexterity) / 3
+	if armed {
+		dice++
+	}
+	return max(dice, 1)
+}
+
+// Roller is the source of randomness for combat. *rand.Rand satisfies it.
+type Roller interface {
+	Intn(n int) int
+}
+
+// State is everything needed to resolve one round of crew dice combat.
+type State struct {
+	Mode         Mode
+	Faces        []string     // the six faces of the player's crew die
+	Dice         int          // how many times the crew die is rolled this round
+	Remaining    Requirements // what the enemy still requires
+	RangedDamage int          // damage dealt to the player if a ranged round fails
+	CloseDamage  int          // damage dealt to the player if a close round fails
+}
+
+// Result is the outcome of a single round of combat.
+type Result struct {
+	Mode      Mode
+	Rolls     []Face
+	Matched   Requirements // symbols that counted against the enemy
+	Remaining Requirements // what the enemy still requires after the round
+	Defeated  bool         // the enemy has no requirements left
+	Damage    int          // damage the player takes for this round
+}
+
+// Resolve rolls the crew die, matches the rolled symbols against the enemy's
+// remaining requirements and works out the damage dealt back to the player
+// if the enemy survives. Symbols beyond what the enemy requires are wasted.
+func Resolve(s State, r Roller) (Result, error) {
+	faces := make([]Face, 0, len(s.Faces))
+	for _, f := range s.Faces {
+		face, err := ParseFace(f)
+		if err != nil {
+			return Result{}, err
+		}
+		faces = append(faces, face)
+	}
+	if len(faces) == 0 && s.Dice > 0 {
+		return Result{}, fmt.Errorf("no crew die faces to roll")
+	}
+
+	result := Result{
+		Mode:      s.Mode,
+		Remaining: s.Remaining,
+	}
+
+	for i := 0; i < s.Dice; i++ {
+		face := faces[r.Intn(len(faces))]
+		result.Rolls = append(result.Rolls, face)
+
+		need := result.Remaining.get(face.Stat)
+		hits := min(face.Count, max(*need, 0))
+		*need -= hits
+		*result.Matched.get(face.Stat) += hits
+	}
+
+	result.Defeated = result.Remaining.Met()
+	if !result.Defeated {
+		result.Damage = s.damage()
+	}
+
+	return result, nil
+}
+
+// damage returns the damage the enemy deals in the state's combat mode.
+func (s State) damage() int {
+	if s.Mode == Close {
+		return s.CloseDamage
+	}
+	return s.RangedDamage
+}
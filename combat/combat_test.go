@@ -0,0 +1,292 @@
+package combat
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// dice is a Roller that rolls the given die results in order, e.g. 6 for a
+// six on a d6.
+type dice struct {
+	t     *testing.T
+	rolls []int
+}
+
+func (d *dice) Intn(n int) int {
+	d.t.Helper()
+	if len(d.rolls) == 0 {
+		d.t.Fatalf("rolled more dice than expected")
+	}
+	roll := d.rolls[0]
+	d.rolls = d.rolls[1:]
+	if roll < 1 || roll > n {
+		d.t.Fatalf("roll %d on a d%d", roll, n)
+	}
+	return roll - 1
+}
+
+// The crew die the tests roll, so a roll of 2 is double dexterity.
+var testFaces = []string{"strength", "double dexterity", "intelligence", "strength", "dexterity", "double strength"}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    State
+		rolls    []int
+		matched  Requirements
+		remain   Requirements
+		defeated bool
+		damage   int
+	}{
+		{
+			name:  "defeated",
+			state: State{Mode: Ranged, Dice: 2, Remaining: Requirements{Strength: 2}, RangedDamage: 2},
+			rolls: []int{1, 6}, matched: Requirements{Strength: 2}, defeated: true,
+		},
+		{
+			name:  "double face counts twice",
+			state: State{Mode: Close, Dice: 1, Remaining: Requirements{Dexterity: 3}, CloseDamage: 1},
+			rolls: []int{2}, matched: Requirements{Dexterity: 2}, remain: Requirements{Dexterity: 1}, damage: 1,
+		},
+		{
+			name:  "ranged failure",
+			state: State{Mode: Ranged, Dice: 2, Remaining: Requirements{Intelligence: 2}, RangedDamage: 2, CloseDamage: 3},
+			rolls: []int{1, 2}, remain: Requirements{Intelligence: 2}, damage: 2,
+		},
+		{
+			name:  "close failure",
+			state: State{Mode: Close, Dice: 1, Remaining: Requirements{Intelligence: 2}, RangedDamage: 2, CloseDamage: 3},
+			rolls: []int{3}, matched: Requirements{Intelligence: 1}, remain: Requirements{Intelligence: 1}, damage: 3,
+		},
+		{
+			name:  "surplus symbols are wasted",
+			state: State{Mode: Ranged, Dice: 1, Remaining: Requirements{Strength: 1, Dexterity: 1}, RangedDamage: 1},
+			rolls: []int{6}, matched: Requirements{Strength: 1}, remain: Requirements{Dexterity: 1}, damage: 1,
+		},
+		{
+			name:  "negative requirement",
+			state: State{Mode: Ranged, Dice: 2, Remaining: Requirements{Strength: -1, Dexterity: 1}, RangedDamage: 1},
+			rolls: []int{1, 5}, matched: Requirements{Dexterity: 1}, remain: Requirements{Strength: -1}, defeated: true,
+		},
+		{
+			name:   "no dice",
+			state:  State{Mode: Ranged, Remaining: Requirements{Strength: 1}, RangedDamage: 2},
+			remain: Requirements{Strength: 1}, damage: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.state.Faces = testFaces
+			d := &dice{t: t, rolls: tt.rolls}
+			got, err := Resolve(tt.state, d)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if len(d.rolls) > 0 {
+				t.Errorf("%d dice not rolled", len(d.rolls))
+			}
+			if got.Matched != tt.matched || got.Remaining != tt.remain {
+				t.Errorf("matched %+v, remaining %+v; want %+v, %+v", got.Matched, got.Remaining, tt.matched, tt.remain)
+			}
+			if got.Defeated != tt.defeated || got.Damage != tt.damage {
+				t.Errorf("Defeated = %v, Damage = %d; want %v, %d", got.Defeated, got.Damage, tt.defeated, tt.damage)
+			}
+			if len(got.Rolls) != tt.state.Dice {
+				t.Errorf("%d rolls, want %d", len(got.Rolls), tt.state.Dice)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	if _, err := Resolve(State{Faces: []string{"strength", "luck"}, Dice: 1}, rng); err == nil {
+		t.Error("Resolve with an unknown face succeeded")
+	}
+	if _, err := Resolve(State{Dice: 1}, rng); err == nil {
+		t.Error("Resolve with no faces succeeded")
+	}
+}
+
+func TestTake(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   Requirements
+		n      int
+		taken  Requirements
+		remain Requirements
+	}{
+		{"largest first", Requirements{3, 1, 0}, 2, Requirements{Strength: 2}, Requirements{1, 1, 0}},
+		{"spread over ties", Requirements{1, 1, 1}, 2, Requirements{Strength: 1, Dexterity: 1}, Requirements{Intelligence: 1}},
+		{"more than required", Requirements{1, 0, 1}, 5, Requirements{Strength: 1, Intelligence: 1}, Requirements{}},
+		{"nothing", Requirements{2, 0, 0}, 0, Requirements{}, Requirements{2, 0, 0}},
+		{"negative requirement", Requirements{-2, 1, 0}, 3, Requirements{Dexterity: 1}, Requirements{Strength: -2}},
+		{"all negative", Requirements{-1, -1, 0}, 2, Requirements{}, Requirements{-1, -1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.from
+			taken := r.Take(tt.n)
+			if taken != tt.taken || r != tt.remain {
+				t.Errorf("Take(%d) took %+v leaving %+v; want %+v leaving %+v", tt.n, taken, r, tt.taken, tt.remain)
+			}
+		})
+	}
+}
+
+func TestFire(t *testing.T) {
+	ballistic := Profile{Ballistic: 1, Energy: 2}
+	tests := []struct {
+		name     string
+		volley   Volley
+		rolls    []int
+		outcomes []Outcome
+		damage   int // total damage of the volley
+		remain   Requirements
+		defeated bool
+		jammed   bool
+		rerolls  int
+	}{
+		{
+			name:   "critical",
+			volley: Volley{Ammo: Ballistic, Rounds: 1, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3},
+			rolls:  []int{6}, outcomes: []Outcome{Critical}, damage: 2, remain: Requirements{Strength: 1},
+		},
+		{
+			name:   "critical capped by requirements",
+			volley: Volley{Ammo: Energy, Rounds: 1, Profile: ballistic, Remaining: Requirements{Dexterity: 3}, Reliability: 3},
+			rolls:  []int{6}, outcomes: []Outcome{Critical}, damage: 3, defeated: true,
+		},
+		{
+			name:   "hit and miss",
+			volley: Volley{Ammo: Ballistic, Rounds: 2, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3},
+			rolls:  []int{4, 2}, outcomes: []Outcome{Hit, Miss}, damage: 1, remain: Requirements{Strength: 2},
+		},
+		{
+			name:   "stops once defeated",
+			volley: Volley{Ammo: Ballistic, Rounds: 3, Profile: ballistic, Remaining: Requirements{Intelligence: 1}, Reliability: 3},
+			rolls:  []int{5}, outcomes: []Outcome{Hit}, damage: 1, defeated: true,
+		},
+		{
+			name:   "jam",
+			volley: Volley{Ammo: Ballistic, Rounds: 3, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3},
+			rolls:  []int{1, 4}, outcomes: []Outcome{Miss}, remain: Requirements{Strength: 3}, jammed: true,
+		},
+		{
+			name:   "jam roll at reliability",
+			volley: Volley{Ammo: Ballistic, Rounds: 2, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3},
+			rolls:  []int{1, 3, 4}, outcomes: []Outcome{Miss, Hit}, damage: 1, remain: Requirements{Strength: 2},
+		},
+		{
+			name:   "reliability 6 never jams",
+			volley: Volley{Ammo: Ballistic, Rounds: 1, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 6},
+			rolls:  []int{1, 6}, outcomes: []Outcome{Miss}, remain: Requirements{Strength: 3},
+		},
+		{
+			name:   "reroll saves a jam",
+			volley: Volley{Ammo: Ballistic, Rounds: 1, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3, Rerolls: 1},
+			rolls:  []int{1, 5}, outcomes: []Outcome{Hit}, damage: 1, remain: Requirements{Strength: 2}, rerolls: 1,
+		},
+		{
+			name:   "jam on a reroll",
+			volley: Volley{Ammo: Ballistic, Rounds: 2, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3, Rerolls: 2},
+			rolls:  []int{2, 1, 6}, outcomes: []Outcome{Miss}, remain: Requirements{Strength: 3}, jammed: true, rerolls: 1,
+		},
+		{
+			name:   "rerolls run out",
+			volley: Volley{Ammo: Ballistic, Rounds: 2, Profile: ballistic, Remaining: Requirements{Strength: 3}, Reliability: 3, Rerolls: 1},
+			rolls:  []int{2, 2, 1, 5}, outcomes: []Outcome{Miss, Miss}, remain: Requirements{Strength: 3}, jammed: true, rerolls: 1,
+		},
+		{
+			name:   "negative requirement",
+			volley: Volley{Ammo: Ballistic, Rounds: 2, Profile: ballistic, Remaining: Requirements{Strength: -1, Dexterity: 1}, Reliability: 3},
+			rolls:  []int{6}, outcomes: []Outcome{Critical}, damage: 1, remain: Requirements{Strength: -1}, defeated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &dice{t: t, rolls: tt.rolls}
+			got, err := Fire(tt.volley, d)
+			if err != nil {
+				t.Fatalf("Fire: %v", err)
+			}
+			if len(d.rolls) > 0 {
+				t.Errorf("%d dice not rolled", len(d.rolls))
+			}
+			var outcomes []Outcome
+			damage := 0
+			for _, shot := range got.Shots {
+				outcomes = append(outcomes, shot.Outcome)
+				damage += shot.Damage
+			}
+			if len(outcomes) != len(tt.outcomes) {
+				t.Fatalf("shots %v, want %v", outcomes, tt.outcomes)
+			}
+			for i := range outcomes {
+				if outcomes[i] != tt.outcomes[i] {
+					t.Errorf("shots %v, want %v", outcomes, tt.outcomes)
+					break
+				}
+			}
+			if damage != tt.damage || got.Remaining != tt.remain {
+				t.Errorf("damage %d leaving %+v, want %d leaving %+v", damage, got.Remaining, tt.damage, tt.remain)
+			}
+			if got.Defeated != tt.defeated || got.Jammed != tt.jammed || got.Rerolls != tt.rerolls {
+				t.Errorf("Defeated = %v, Jammed = %v, Rerolls = %d; want %v, %v, %d", got.Defeated, got.Jammed, got.Rerolls, tt.defeated, tt.jammed, tt.rerolls)
+			}
+			if tt.jammed && !got.Shots[len(got.Shots)-1].Jammed {
+				t.Error("the last shot is not marked as the one that jammed")
+			}
+		})
+	}
+
+	if _, err := Fire(Volley{Ammo: Ballistic, Profile: ballistic}, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Fire with no rounds succeeded")
+	}
+}
+
+// TestFireSeeded fires random volleys and checks what every volley must
+// keep to, whatever the dice roll.
+func TestFireSeeded(t *testing.T) {
+	for seed := int64(1); seed <= 300; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		v := Volley{
+			Ammo:        Energy,
+			Rounds:      rng.Intn(4) + 1,
+			Profile:     Profile{Energy: rng.Intn(3)},
+			Remaining:   Requirements{rng.Intn(5) - 1, rng.Intn(5) - 1, rng.Intn(5) - 1},
+			Reliability: rng.Intn(6) + 1,
+			Rerolls:     rng.Intn(3),
+		}
+		got, err := Fire(v, rng)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		damage, jams := 0, 0
+		for _, shot := range got.Shots {
+			damage += shot.Damage
+			if shot.Jammed {
+				jams++
+			}
+			if shot.Damage > v.Profile.Energy*critMultiplier {
+				t.Errorf("seed %d: shot dealt %d damage", seed, shot.Damage)
+			}
+		}
+		if len(got.Shots) > v.Rounds || (len(got.Shots) == 0 && !v.Remaining.Met()) {
+			t.Errorf("seed %d: %d shots from %d rounds", seed, len(got.Shots), v.Rounds)
+		}
+		if damage != v.Remaining.Total()-got.Remaining.Total() {
+			t.Errorf("seed %d: %d damage took %+v to %+v", seed, damage, v.Remaining, got.Remaining)
+		}
+		if got.Defeated != got.Remaining.Met() {
+			t.Errorf("seed %d: Defeated = %v leaving %+v", seed, got.Defeated, got.Remaining)
+		}
+		if got.Jammed != (jams == 1) || jams > 1 {
+			t.Errorf("seed %d: Jammed = %v after %d jams", seed, got.Jammed, jams)
+		}
+		if got.Rerolls > v.Rerolls {
+			t.Errorf("seed %d: %d rerolls of %d", seed, got.Rerolls, v.Rerolls)
+		}
+	}
+}
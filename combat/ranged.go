@@ -0,0 +1,172 @@
+package combat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ammo is the kind of damage a ranged weapon deals.
+type Ammo string
+
+const (
+	Ballistic Ammo = "ballistic"
+	Energy    Ammo = "energy"
+	Explosive Ammo = "explosive"
+)
+
+// ParseAmmo turns a weapon's ammo type, e.g. "Energy", into an Ammo.
+func ParseAmmo(s string) (Ammo, error) {
+	switch a := Ammo(strings.ToLower(strings.TrimSpace(s))); a {
+	case Ballistic, Energy, Explosive:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown ammo type %q", s)
+	}
+}
+
+// Profile is how much each kind of ammo hurts an enemy: the number of
+// symbols a hit removes from what the enemy requires.
+type Profile struct {
+	Ballistic int
+	Energy    int
+	Explosive int
+}
+
+// Damage returns the damage a hit with the given ammo deals.
+func (p Profile) Damage(a Ammo) int {
+	switch a {
+	case Ballistic:
+		return p.Ballistic
+	case Energy:
+		return p.Energy
+	case Explosive:
+		return p.Explosive
+	default:
+		return 0
+	}
+}
+
+// Outcome is how a single shot landed.
+type Outcome int
+
+const (
+	Miss Outcome = iota
+	Hit
+	Critical
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Miss:
+		return "miss"
+	case Hit:
+		return "hit"
+	case Critical:
+		return "critical hit"
+	default:
+		return "unknown"
+	}
+}
+
+// Faces of the ammo die: 1-2 miss, 3-5 hit, 6 is a critical hit for double
+// damage. A 1 may also jam the weapon, see Volley.Reliability.
+const (
+	ammoDieSides   = 6
+	ammoDieJam     = 1
+	ammoDieHit     = 3
+	ammoDieCrit    = 6
+	critMultiplier = 2
+)
+
+// Volley is a burst of rounds fired at an enemy.
+type Volley struct {
+	Ammo      Ammo
+	Rounds    int          // rounds fired, one ammo die each
+	Profile   Profile      // the enemy's damage profile
+	Remaining Requirements // what the enemy still requires
+
+	// Reliability is checked whenever the ammo die rolls a 1: a d6 roll
+	// above it jams the weapon and ends the volley, so only 6 never jams.
+	Reliability int
+
+	// Rerolls is how many missed shots may be rolled again, e.g. from a
+	// targeting implant.
+	Rerolls int
+}
+
+// Shot is the result of one round fired.
+type Shot struct {
+	Roll    int // the ammo die
+	Outcome Outcome
+	Damage  int          // damage the shot dealt before the enemy's requirements ran out
+	Matched Requirements // the symbols the damage removed
+	Jammed  bool         // the weapon jammed on this shot
+	Retaken bool         // the shot missed and was rolled again
+}
+
+// VolleyResult is the outcome of a volley.
+type VolleyResult struct {
+	Shots     []Shot
+	Remaining Requirements // what the enemy still requires after the volley
+	Defeated  bool         // the enemy has no requirements left
+	Jammed    bool         // the weapon jammed and has to be cleared
+	Rerolls   int          // how many of the volley's rerolls were used
+}
+
+// Fire rolls the ammo die for each round of a volley and takes the damage
+// each shot deals off the enemy's requirements, largest first. Firing stops
+// early if the enemy is defeated, so no rounds are wasted on a dead enemy,
+// or if the weapon jams.
+func Fire(v Volley, r Roller) (VolleyResult, error) {
+	if v.Rounds < 1 {
+		return VolleyResult{}, fmt.Errorf("no rounds to fire")
+	}
+
+	result := VolleyResult{Remaining: v.Remaining}
+	for i := 0; i < v.Rounds && !result.Remaining.Met() && !result.Jammed; i++ {
+		shot := Shot{Roll: r.Intn(ammoDieSides) + 1}
+
+		// A missed shot can be taken again before it can jam the weapon
+		if shot.Roll < ammoDieHit && result.Rerolls < v.Rerolls {
+			shot.Roll = r.Intn(ammoDieSides) + 1
+			shot.Retaken = true
+			result.Rerolls++
+		}
+
+		switch {
+		case shot.Roll >= ammoDieCrit:
+			shot.Outcome = Critical
+			shot.Damage = v.Profile.Damage(v.Ammo) * critMultiplier
+		case shot.Roll >= ammoDieHit:
+			shot.Outcome = Hit
+			shot.Damage = v.Profile.Damage(v.Ammo)
+		case shot.Roll == ammoDieJam:
+			shot.Jammed = r.Intn(ammoDieSides)+1 > v.Reliability
+			result.Jammed = shot.Jammed
+		}
+
+		shot.Matched = result.Remaining.Take(shot.Damage)
+		shot.Damage = shot.Matched.Total()
+		result.Shots = append(result.Shots, shot)
+	}
+
+	result.Defeated = result.Remaining.Met()
+	return result, nil
+}
+
+// Take removes up to n symbols from the requirements, one at a time from
+// whichever is largest, and returns what was removed.
+func (r *Requirements) Take(n int) Requirements {
+	var taken Requirements
+	for ; n > 0 && !r.Met(); n-- {
+		largest := Strength
+		for _, s := range []Stat{Dexterity, Intelligence} {
+			if *r.get(s) > *r.get(largest) {
+				largest = s
+			}
+		}
+		*r.get(largest) -= 1
+		*taken.get(largest) += 1
+	}
+	return taken
+}
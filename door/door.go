@@ -4,8 +4,6 @@ package door
 
 import (
 	"bufio"
-	"errors"
-	"fmt"
 	"log"
 	"os"
 	"regexp"
@@ -15,7 +13,6 @@ import (
 	"unicode"
 	"unicode/utf8"
 
-	"github.com/eiannone/keyboard"
 	"golang.org/x/text/encoding/charmap"
 )
 
@@ -101,48 +98,42 @@ const (
 	Reset = Esc + "0m"
 )
 
-func PrintColoredBlock(xColStart, numCols, yRowStart, numRows int, bgColor string) {
+func (t *Terminal) PrintColoredBlock(xColStart, numCols, yRowStart, numRows int, bgColor string) {
 	// Create a blank row with the specified number of columns
 	blankRow := strings.Repeat(" ", numCols)
 
 	// Print the rows before the block
 	for i := 0; i < yRowStart; i++ {
-		fmt.Println()
+		t.Println()
 	}
 
 	// Print the colored block
 	for i := 0; i < numRows; i++ {
 		// Move the cursor to the starting column
-		fmt.Printf("\033[%dG", xColStart)
+		t.Printf("\033[%dG", xColStart)
 
 		// Print the colored row
-		fmt.Printf("%s%s\033[0m\n", bgColor, blankRow)
+		t.Printf("%s%s\033[0m\n", bgColor, blankRow)
 	}
 }
 
 // ClearScreenAndDisplay clears the screen and displays an ANSI file.
-func ClearScreenAndDisplay(filename string) {
-	ClearScreen()
-	DisplayAnsiFile(filename, false)
+func (t *Terminal) ClearScreenAndDisplay(filename string) {
+	t.ClearScreen()
+	t.DisplayAnsiFile(filename, false)
 }
 
 // HandleInvalidInput displays an error message for invalid input.
-func HandleInvalidInput() {
-	MoveCursor(2, 23)
-	fmt.Printf("%s%sInvalid choice.%s", BgRed, RedHi, Reset)
+func (t *Terminal) HandleInvalidInput() {
+	t.MoveCursor(2, 23)
+	t.Printf("%s%sInvalid choice.%s", BgRed, RedHi, Reset)
 	time.Sleep(1 * time.Second)
-	MoveCursor(2, 23)
-	fmt.Printf("%s%s               %s", BgRed, RedHi, Reset)
+	t.MoveCursor(2, 23)
+	t.Printf("%s%s               %s", BgRed, RedHi, Reset)
 }
 
-func GetKeyboardInput() (string, error) {
-	err := keyboard.Open()
-	if err != nil {
-		return "", err
-	}
-	defer keyboard.Close()
-
-	char, _, err := keyboard.GetSingleKey()
+func (t *Terminal) GetKeyboardInput() (string, error) {
+	char, err := t.ReadKey()
 	if err != nil {
 		return "", err
 	}
@@ -151,81 +142,63 @@ func GetKeyboardInput() (string, error) {
 }
 
 // Prompt the user and get their choice
-func PromptYesNo(question string) (string, error) {
+func (t *Terminal) PromptYesNo(question string) (string, error) {
 	// Print the prompt
-	fmt.Printf("%s (yes/no)", question)
-
-	// Open keyboard listener
-	err := keyboard.Open()
-	if err != nil {
-		return "", err
-	}
-	defer keyboard.Close()
+	t.Printf("%s (yes/no)", question)
 
 	// Listen for single key press
-	char, _, err := keyboard.GetSingleKey()
+	char, err := t.ReadKey()
 	if err != nil {
 		return "", err
 	}
 
 	// Convert the pressed key to lowercase string
-	choice := string(unicode.ToLower(rune(char)))
+	choice := string(unicode.ToLower(char))
 
 	// Check if the choice is valid
 	if choice != "y" && choice != "n" {
-		fmt.Println("Invalid choice. Please enter 'y' or 'n'.")
-		return PromptYesNo(question)
+		t.Println("Invalid choice. Please enter 'y' or 'n'.")
+		return t.PromptYesNo(question)
 	}
 
 	return choice, nil
 }
 
 // Move cursor to X, Y location
-func MoveCursor(x int, y int) {
-	fmt.Printf(Esc+"%d;%df", y, x)
+func (t *Terminal) MoveCursor(x int, y int) {
+	t.Printf(Esc+"%d;%df", y, x)
 }
 
 // Erase the screen
-func ClearScreen() {
-	fmt.Println(EraseScreen)
-	MoveCursor(0, 0)
+func (t *Terminal) ClearScreen() {
+	t.Println(EraseScreen)
+	t.MoveCursor(0, 0)
 }
 
 // Show the cursor.
-func CursorShow() {
-	fmt.Print(Esc + "?25h")
+func (t *Terminal) CursorShow() {
+	t.Print(Esc + "?25h")
 }
 
 // Hide the cursor.
-func CursorHide() {
-	fmt.Print(Esc + "?25l")
+func (t *Terminal) CursorHide() {
+	t.Print(Esc + "?25l")
 }
 
 // WaitForAnyKey waits for a user to press any key to continue.
-func WaitForAnyKey() error {
-	// Open the keyboard listener
-	err := keyboard.Open()
-	if err != nil {
-		return err
-	}
-	defer keyboard.Close() // Ensure that the keyboard listener is closed when done
-
+func (t *Terminal) WaitForAnyKey() error {
 	// Wait for a single key press
-	_, _, err = keyboard.GetSingleKey()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	_, err := t.ReadKey()
+	return err
 }
 
-func DisplayAnsiFile(filePath string, localDisplay bool) {
+func (t *Terminal) DisplayAnsiFile(filePath string, localDisplay bool) {
 	content, err := ReadAnsiFile(filePath)
 	if err != nil {
 		log.Fatalf("Error reading file %s: %v", filePath, err)
 	}
-	ClearScreen()
-	PrintAnsi(content, 0, localDisplay)
+	t.ClearScreen()
+	t.PrintAnsi(content, 0, localDisplay)
 }
 
 func ReadAnsiFile(filePath string) (string, error) {
@@ -237,7 +210,7 @@ func ReadAnsiFile(filePath string) (string, error) {
 }
 
 // Print ANSI art with a delay between lines
-func PrintAnsi(artContent string, delay int, localDisplay bool) { // localDisplay as an argument for UTF-8 conversion
+func (t *Terminal) PrintAnsi(artContent string, delay int, localDisplay bool) { // localDisplay as an argument for UTF-8 conversion
 	noSauce := TrimStringFromSauce(artContent) // strip off the SAUCE metadata
 	lines := strings.Split(noSauce, "\r\n")
 
@@ -246,23 +219,23 @@ func PrintAnsi(artContent string, delay int, localDisplay bool) { // localDispla
 			// Convert line from CP437 to UTF-8
 			utf8Line, err := charmap.CodePage437.NewDecoder().String(line)
 			if err != nil {
-				fmt.Printf("Error converting to UTF-8: %v\n", err)
+				t.Printf("Error converting to UTF-8: %v\n", err)
 				continue
 			}
 			line = utf8Line
 		}
 
 		if i < len(lines)-1 && i != 24 { // Check for the 25th line (index 24)
-			fmt.Println(line) // Print with a newline
+			t.Println(line) // Print with a newline
 		} else {
-			fmt.Print(line) // Print without a newline (for the 25th line and the last line of the art)
+			t.Print(line) // Print without a newline (for the 25th line and the last line of the art)
 		}
 		time.Sleep(time.Duration(delay) * time.Millisecond)
 	}
 }
 
 // Print ANSI art at an X, Y location after removing SAUCE metadata
-func PrintAnsiLoc(artfile string, x, y int) error {
+func (t *Terminal) PrintAnsiLoc(artfile string, x, y int) error {
 	// Open the file
 	file, err := os.Open(artfile)
 	if err != nil {
@@ -274,15 +247,15 @@ func PrintAnsiLoc(artfile string, x, y int) error {
 	scanner := bufio.NewScanner(file)
 
 	// Move to the specified Y coordinate
-	fmt.Printf("\033[%d;%df", y, x)
+	t.Printf("\033[%d;%df", y, x)
 
 	// Read and print each line at the specified location after removing SAUCE metadata
 	for scanner.Scan() {
 		line := scanner.Text()
 		line = TrimStringFromSauce(line)
-		fmt.Printf("%s\n", line)
+		t.Printf("%s\n", line)
 		y++
-		fmt.Printf("\033[%d;%df", y, x) // Move to the next line at the specified X coordinate
+		t.Printf("\033[%d;%df", y, x) // Move to the next line at the specified X coordinate
 	}
 
 	// Check for any scanner errors
@@ -318,8 +291,8 @@ func trimLastChar(s string) string {
 }
 
 // Print text at an X, Y location
-func PrintStringLoc(text string, x int, y int) {
-	fmt.Fprintf(os.Stdout, Esc+strconv.Itoa(y)+";"+strconv.Itoa(x)+"f"+text)
+func (t *Terminal) PrintStringLoc(text string, x int, y int) {
+	t.Print(Esc + strconv.Itoa(y) + ";" + strconv.Itoa(x) + "f" + text)
 }
 
 // CenterAlignText center-aligns text while preserving ANSI escape sequences and supports foreground and background colors.
@@ -398,60 +371,3 @@ func RightAlignText(text string, width int, foreground, background string) strin
 
 	return alignedText.String()
 }
-
-func DropFileData(path string) (string, int, int, int, error) {
-	// Append trailing slash to path if it doesn't exist
-	if !strings.HasSuffix(path, "/") {
-		path += "/"
-	}
-
-	// Check if the file exists
-	fileInfo, err := os.Stat(strings.ToLower(path + "door32.sys"))
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "", 0, 0, 0, errors.New("file does not exist")
-		}
-		return "", 0, 0, 0, err
-	}
-
-	// Check if the file is empty
-	if fileInfo.Size() == 0 {
-		return "", 0, 0, 0, errors.New("file is empty")
-	}
-
-	// Open the file
-	file, err := os.Open(strings.ToLower(path + "door32.sys"))
-	if err != nil {
-		return "", 0, 0, 0, err
-	}
-	defer file.Close()
-
-	// Read lines from the file
-	scanner := bufio.NewScanner(file)
-	var text []string
-	for scanner.Scan() {
-		text = append(text, scanner.Text())
-	}
-
-	// Extract drop file data
-	dropAlias := text[6]
-	dropTimeLeft := text[8]
-	dropEmulation := text[9]
-	nodeNum := text[10]
-
-	// Convert timeLeft and emulation to integers
-	timeInt, err := strconv.Atoi(dropTimeLeft)
-	if err != nil {
-		return "", 0, 0, 0, err
-	}
-	emuInt, err := strconv.Atoi(dropEmulation)
-	if err != nil {
-		return "", 0, 0, 0, err
-	}
-	nodeInt, err := strconv.Atoi(nodeNum)
-	if err != nil {
-		return "", 0, 0, 0, err
-	}
-
-	return dropAlias, timeInt, emuInt, nodeInt, nil
-}
@@ -0,0 +1,155 @@
+package door
+
+import (
+	"io"
+	"net"
+)
+
+// Telnet protocol bytes (RFC 854) and the options the door negotiates.
+const (
+	telnetSE   = 240 // end of subnegotiation
+	telnetSB   = 250 // start of subnegotiation
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255 // interpret as command
+
+	telnetOptBinary = 0
+	telnetOptEcho   = 1
+	telnetOptSGA    = 3 // suppress go-ahead
+)
+
+// NewTelnetTerminal returns a Terminal for a telnet connection handed over by
+// the BBS. The door takes over echo, suppresses go-ahead and switches the
+// line to binary mode so CP437 art arrives intact.
+func NewTelnetTerminal(conn net.Conn) (*Terminal, error) {
+	negotiation := []byte{
+		telnetIAC, telnetWILL, telnetOptEcho,
+		telnetIAC, telnetWILL, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptSGA,
+		telnetIAC, telnetWILL, telnetOptBinary,
+		telnetIAC, telnetDO, telnetOptBinary,
+	}
+	if _, err := conn.Write(negotiation); err != nil {
+		return nil, err
+	}
+
+	t := NewTerminal(&telnetReader{r: conn}, &telnetWriter{w: conn})
+	t.closer = conn.Close
+	return t, nil
+}
+
+// Parser states for incoming telnet data.
+const (
+	telnetData   = iota // plain data
+	telnetCmd           // after IAC
+	telnetOpt           // after IAC WILL/WONT/DO/DONT, waiting for the option
+	telnetSub           // inside a subnegotiation
+	telnetSubCmd        // after IAC inside a subnegotiation
+)
+
+// telnetReader strips telnet command sequences from the incoming stream so
+// only the user's key presses are returned.
+type telnetReader struct {
+	r      io.Reader
+	buf    []byte
+	state  int
+	lastCR bool // the previous data byte was a carriage return
+}
+
+func (t *telnetReader) Read(p []byte) (int, error) {
+	if len(t.buf) < len(p) {
+		t.buf = make([]byte, len(p))
+	}
+
+	for {
+		n, err := t.r.Read(t.buf[:len(p)])
+		out := 0
+		for _, b := range t.buf[:n] {
+			if t.filter(b) {
+				p[out] = b
+				out++
+			}
+		}
+
+		// Keep reading when a packet only held telnet commands
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
+
+// filter advances the parser by one byte and reports whether the byte is user data.
+func (t *telnetReader) filter(b byte) bool {
+	switch t.state {
+	case telnetCmd:
+		switch b {
+		case telnetIAC:
+			t.state = telnetData
+			return true // escaped 0xFF
+		case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+			t.state = telnetOpt
+		case telnetSB:
+			t.state = telnetSub
+		default:
+			t.state = telnetData
+		}
+		return false
+	case telnetOpt:
+		t.state = telnetData
+		return false
+	case telnetSub:
+		if b == telnetIAC {
+			t.state = telnetSubCmd
+		}
+		return false
+	case telnetSubCmd:
+		if b == telnetSE {
+			t.state = telnetData
+		} else {
+			t.state = telnetSub
+		}
+		return false
+	}
+
+	if b == telnetIAC {
+		t.state = telnetCmd
+		return false
+	}
+
+	// Enter arrives as CR NUL or CR LF; only the CR is a key press
+	lastCR := t.lastCR
+	t.lastCR = b == '\r'
+	if lastCR && (b == 0 || b == '\n') {
+		return false
+	}
+	return true
+}
+
+// telnetWriter escapes IAC bytes in outgoing data and turns bare line feeds
+// into CR LF, the way a local tty does.
+type telnetWriter struct {
+	w      io.Writer
+	lastCR bool
+}
+
+func (t *telnetWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p)+len(p)/8)
+	for _, b := range p {
+		switch {
+		case b == telnetIAC:
+			out = append(out, telnetIAC, telnetIAC)
+		case b == '\n' && !t.lastCR:
+			out = append(out, '\r', '\n')
+		default:
+			out = append(out, b)
+		}
+		t.lastCR = b == '\r'
+	}
+
+	if _, err := t.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
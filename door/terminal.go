@@ -0,0 +1,256 @@
+package door
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"spacejunk3000/dropfile"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eiannone/keyboard"
+)
+
+// Terminal is a connection to the user: everything the door prints goes to
+// its writer and every key press is read from its reader. Wrapping an
+// io.Reader/io.Writer pair lets the game run over a local console, a socket
+// or a scripted session in the same way.
+type Terminal struct {
+	in     *bufio.Reader
+	out    io.Writer
+	closer func() error
+
+	keys     chan keyPress // key presses read in the background, see ReadKey
+	deadline time.Time     // when the user's BBS time runs out, zero for no limit
+	idle     time.Duration // how long to wait for a key press, zero for no limit
+
+	hangup     chan struct{} // closed once the user's connection is gone
+	hangupOnce sync.Once
+}
+
+// keyPress is a key read from the terminal, or the error that stopped reading.
+type keyPress struct {
+	char rune
+	err  error
+}
+
+// Errors returned by ReadKey when the user's session is over.
+var (
+	ErrTimeUp = errors.New("time limit reached")
+	ErrIdle   = errors.New("inactivity timeout")
+	ErrHangup = errors.New("connection lost")
+)
+
+// NewTerminal returns a Terminal that reads key presses from r and writes
+// output to w.
+func NewTerminal(r io.Reader, w io.Writer) *Terminal {
+	return &Terminal{
+		in:     bufio.NewReader(r),
+		out:    w,
+		hangup: make(chan struct{}),
+	}
+}
+
+// NewLocalTerminal returns a Terminal for the local console. The keyboard is
+// put into raw mode until the Terminal is closed.
+func NewLocalTerminal() (*Terminal, error) {
+	if err := keyboard.Open(); err != nil {
+		return nil, err
+	}
+
+	t := NewTerminal(keyboardReader{}, os.Stdout)
+	t.closer = keyboard.Close
+	return t, nil
+}
+
+// Close releases whatever the Terminal holds open, such as raw keyboard mode.
+func (t *Terminal) Close() error {
+	if t.closer == nil {
+		return nil
+	}
+	err := t.closer()
+	t.closer = nil
+	return err
+}
+
+// Hangup marks the user's connection as gone, e.g. on SIGHUP. Pending and
+// future calls to ReadKey return ErrHangup and output is discarded, so the
+// game can unwind and save instead of exiting where it stands.
+func (t *Terminal) Hangup() {
+	t.hangupOnce.Do(func() {
+		close(t.hangup)
+	})
+}
+
+// HungUp reports whether the user's connection is gone.
+func (t *Terminal) HungUp() bool {
+	select {
+	case <-t.hangup:
+		return true
+	default:
+		return false
+	}
+}
+
+// Write writes raw bytes to the terminal, so a Terminal can be used as an
+// io.Writer. A failed write, such as a broken pipe, means the user is gone.
+func (t *Terminal) Write(p []byte) (int, error) {
+	if t.HungUp() {
+		return len(p), nil
+	}
+	n, err := t.out.Write(p)
+	if err != nil {
+		t.Hangup()
+	}
+	return n, err
+}
+
+// Printf formats according to a format specifier and writes to the terminal.
+func (t *Terminal) Printf(format string, a ...interface{}) {
+	fmt.Fprintf(t, format, a...)
+}
+
+// Print writes its operands to the terminal.
+func (t *Terminal) Print(a ...interface{}) {
+	fmt.Fprint(t, a...)
+}
+
+// Println writes its operands to the terminal followed by a newline.
+func (t *Terminal) Println(a ...interface{}) {
+	fmt.Fprintln(t, a...)
+}
+
+// SetTimeLimit ends the session once d has passed, e.g. the minutes left
+// given in the drop file.
+func (t *Terminal) SetTimeLimit(d time.Duration) {
+	t.deadline = time.Now().Add(d)
+}
+
+// SetIdleTimeout ends the session if no key is pressed for d. Zero disables
+// the timeout.
+func (t *Terminal) SetIdleTimeout(d time.Duration) {
+	t.idle = d
+}
+
+// TimeLeft returns how long the session has left, or -1 if it has no time limit.
+func (t *Terminal) TimeLeft() time.Duration {
+	if t.deadline.IsZero() {
+		return -1
+	}
+	return max(time.Until(t.deadline), 0)
+}
+
+// ReadKey waits for a single key press and returns it. It returns ErrTimeUp
+// once the session's time limit is reached, ErrIdle if the user does not
+// press a key within the idle timeout and ErrHangup once the connection is
+// gone, including when the other end closes it.
+func (t *Terminal) ReadKey() (rune, error) {
+	if t.HungUp() {
+		return 0, ErrHangup
+	}
+	if t.keys == nil {
+		t.keys = make(chan keyPress, 16)
+		go t.readKeys()
+	}
+
+	var timeUp, idle <-chan time.Time
+	if !t.deadline.IsZero() {
+		left := time.Until(t.deadline)
+		if left <= 0 {
+			return 0, ErrTimeUp
+		}
+		timer := time.NewTimer(left)
+		defer timer.Stop()
+		timeUp = timer.C
+	}
+	if t.idle > 0 {
+		timer := time.NewTimer(t.idle)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	select {
+	case key := <-t.keys:
+		if key.err != nil {
+			t.Hangup()
+			return 0, fmt.Errorf("%w: %v", ErrHangup, key.err)
+		}
+		return key.char, nil
+	case <-t.hangup:
+		return 0, ErrHangup
+	case <-timeUp:
+		return 0, ErrTimeUp
+	case <-idle:
+		return 0, ErrIdle
+	}
+}
+
+// readKeys reads key presses in the background so ReadKey can time out.
+func (t *Terminal) readKeys() {
+	for {
+		r, _, err := t.in.ReadRune()
+		t.keys <- keyPress{char: r, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// keyboardReader adapts the raw local keyboard to an io.Reader, encoding each
+// key press as UTF-8.
+type keyboardReader struct{}
+
+func (keyboardReader) Read(p []byte) (int, error) {
+	for {
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return 0, err
+		}
+
+		// Special keys such as Enter, Esc and Space have no character but
+		// their key codes match the ASCII control codes
+		if char == 0 {
+			if key >= utf8.RuneSelf || key == 0 {
+				continue // Arrow and function keys are not used
+			}
+			char = rune(key)
+		}
+
+		if len(p) < utf8.RuneLen(char) {
+			return 0, io.ErrShortBuffer
+		}
+		return utf8.EncodeRune(p, char), nil
+	}
+}
+
+// OpenTerminal returns the Terminal for the connection described by a drop
+// file: the local console, the telnet socket inherited from the BBS, or
+// standard input and output for a serial line.
+func OpenTerminal(commType, commHandle int) (*Terminal, error) {
+	switch commType {
+	case dropfile.CommLocal:
+		return NewLocalTerminal()
+	case dropfile.CommSerial:
+		// BBS software on Linux redirects the caller's line to standard
+		// input and output for doors that are only given a COM port
+		return NewTerminal(os.Stdin, os.Stdout), nil
+	case dropfile.CommTelnet:
+		// The BBS leaves the connected socket open as this descriptor
+		f := os.NewFile(uintptr(commHandle), "door32-socket")
+		if f == nil {
+			return nil, fmt.Errorf("invalid socket handle %d", commHandle)
+		}
+		conn, err := net.FileConn(f)
+		f.Close() // FileConn holds its own copy of the descriptor
+		if err != nil {
+			return nil, fmt.Errorf("failed to open socket handle %d: %v", commHandle, err)
+		}
+		return NewTelnetTerminal(conn)
+	default:
+		return nil, fmt.Errorf("unsupported comm type %d", commType)
+	}
+}
@@ -0,0 +1,53 @@
+package dropfile
+
+import (
+	"strconv"
+)
+
+// parseChainTxt reads a CHAIN.TXT (WWIV) file. Only the lines the door uses are read:
+//
+//	2  alias
+//	3  real name
+//	11 security level
+//	14 ANSI (1 yes, 0 no)
+//	15 remote (1 yes, 0 local)
+//	16 seconds left
+//	20 baud rate
+//	21 COM port
+//	32 node number, only written by newer versions
+func parseChainTxt(name string, lines []string) (*DropInfo, error) {
+	f := newFields("CHAIN.TXT", lines)
+	info := &DropInfo{Format: f.format, Node: 1}
+
+	info.Alias = f.name(2, "alias")
+	info.RealName = f.text(3, "real name")
+	info.SecurityLevel = f.number(11, "security level")
+	info.Emulation = f.bounded(14, "ANSI", EmulationASCII, EmulationANSI)
+	remote := f.bounded(15, "remote", 0, 1)
+
+	// WWIV writes the seconds left with a fractional part
+	seconds := f.text(16, "seconds left")
+	if f.err == nil {
+		secs, err := strconv.ParseFloat(seconds, 64)
+		if err != nil || secs < 0 {
+			f.fail(16, "seconds left", seconds, ErrBadNumber)
+		}
+		info.TimeLeft = int(secs) / 60
+	}
+
+	info.Baud = f.number(20, "baud rate")
+	port := f.number(21, "COM port")
+	if remote != 0 {
+		info.CommType = CommSerial
+		info.CommHandle = port
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if node, err := strconv.Atoi(f.optional(32)); err == nil && node > 0 {
+		info.Node = node
+	}
+
+	return info, nil
+}
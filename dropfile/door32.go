@@ -0,0 +1,35 @@
+package dropfile
+
+// parseDoor32 reads a DOOR32.SYS file:
+//
+//	1  comm type (0 local, 1 serial, 2 telnet)
+//	2  comm handle
+//	3  baud rate
+//	4  BBS software name and version
+//	5  user record number
+//	6  real name
+//	7  alias
+//	8  security level
+//	9  time left in minutes
+//	10 emulation (0 ASCII, 1 ANSI, 2 Avatar, 3 RIP, 4 Max graphics)
+//	11 node number
+func parseDoor32(name string, lines []string) (*DropInfo, error) {
+	f := newFields("DOOR32.SYS", lines)
+	info := &DropInfo{
+		Format:        f.format,
+		CommType:      f.bounded(1, "comm type", CommLocal, CommTelnet),
+		CommHandle:    f.count(2, "comm handle"),
+		Baud:          f.number(3, "baud rate"),
+		RealName:      f.text(6, "real name"),
+		Alias:         f.name(7, "alias"),
+		SecurityLevel: f.number(8, "security level"),
+		TimeLeft:      f.count(9, "time left"),
+		Emulation:     f.bounded(10, "emulation", EmulationASCII, 4),
+		Node:          f.count(11, "node"),
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,46 @@
+package dropfile
+
+import (
+	"strings"
+)
+
+// parseDoorSys reads a DOOR.SYS (GAP) file. Only the lines the door uses are read:
+//
+//	1  COM port, "COM0:" when local
+//	2  baud rate
+//	4  node number
+//	10 user's full name
+//	15 security level
+//	19 time left in minutes
+//	20 graphics mode ("GR" ANSI, "NG" ASCII, "RIP")
+//	36 alias, only in the 52 line version
+func parseDoorSys(name string, lines []string) (*DropInfo, error) {
+	f := newFields("DOOR.SYS", lines)
+	info := &DropInfo{Format: f.format}
+
+	info.CommType, info.CommHandle = f.comPort(1, "COM port")
+	info.Baud = f.number(2, "baud rate")
+	info.Node = f.count(4, "node")
+	info.RealName = f.name(10, "user name")
+	info.SecurityLevel = f.number(15, "security level")
+	info.TimeLeft = f.count(19, "time left")
+	switch strings.ToUpper(f.text(20, "graphics mode")) {
+	case "GR":
+		info.Emulation = EmulationANSI
+	case "RIP":
+		info.Emulation = EmulationRIP
+	default:
+		info.Emulation = EmulationASCII
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	// Older 31 line files have no alias; fall back to the user's name
+	info.Alias = f.optional(36)
+	if info.Alias == "" {
+		info.Alias = info.RealName
+	}
+
+	return info, nil
+}
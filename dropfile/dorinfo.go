@@ -0,0 +1,74 @@
+package dropfile
+
+import (
+	"strconv"
+	"strings"
+)
+
+// isDorinfo matches DORINFO.DEF and DORINFOx.DEF, where x is the node
+// number 1-9 or a letter for nodes 10 and up.
+func isDorinfo(name string) bool {
+	name = strings.ToLower(name)
+	if !strings.HasPrefix(name, "dorinfo") || !strings.HasSuffix(name, ".def") {
+		return false
+	}
+	return len(name) <= len("dorinfox.def")
+}
+
+// dorinfoNode returns the node number encoded in a DORINFOx.DEF file name.
+func dorinfoNode(name string) int {
+	x := strings.TrimSuffix(strings.ToLower(name), ".def")[len("dorinfo"):]
+	if x == "" {
+		return 1
+	}
+	if n, err := strconv.Atoi(x); err == nil {
+		return n
+	}
+	if c := x[0]; c >= 'a' && c <= 'z' {
+		return int(c-'a') + 10
+	}
+	return 1
+}
+
+// parseDorinfo reads a DORINFOx.DEF (RBBS/QuickBBS) file:
+//
+//	1  BBS name
+//	2  sysop first name
+//	3  sysop last name
+//	4  COM port, "COM0" when local
+//	5  baud rate, e.g. "38400 BAUD,N,8,1"
+//	6  networked flag
+//	7  user first name
+//	8  user last name
+//	9  user location
+//	10 emulation (0 ASCII, 1 ANSI, 2 Avatar)
+//	11 security level
+//	12 time left in minutes
+func parseDorinfo(name string, lines []string) (*DropInfo, error) {
+	f := newFields("DORINFO.DEF", lines)
+	info := &DropInfo{Format: f.format, Node: dorinfoNode(name)}
+
+	info.CommType, info.CommHandle = f.comPort(4, "COM port")
+
+	// Only the leading number of "38400 BAUD,N,8,1" is the baud rate
+	if baud := strings.Fields(f.name(5, "baud rate")); len(baud) > 0 {
+		v, err := strconv.Atoi(baud[0])
+		if err != nil {
+			f.fail(5, "baud rate", f.optional(5), ErrBadNumber)
+		}
+		info.Baud = v
+	}
+
+	first := f.name(7, "first name")
+	last := f.text(8, "last name")
+	info.RealName = strings.TrimSpace(first + " " + last)
+	info.Alias = info.RealName
+	info.Emulation = f.bounded(10, "emulation", EmulationASCII, EmulationAvatar)
+	info.SecurityLevel = f.number(11, "security level")
+	info.TimeLeft = f.count(12, "time left")
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return info, nil
+}
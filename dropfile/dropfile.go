@@ -0,0 +1,261 @@
+// BBS drop file parsing. Each supported format has its own parser and all of
+// them return the same DropInfo describing the user and their connection.
+
+package dropfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Comm types, as used on line 1 of door32.sys.
+const (
+	CommLocal  = 0
+	CommSerial = 1
+	CommTelnet = 2
+)
+
+// Emulation types, as used on line 10 of door32.sys.
+const (
+	EmulationASCII  = 0
+	EmulationANSI   = 1
+	EmulationAvatar = 2
+	EmulationRIP    = 3
+)
+
+// DropInfo is what the door needs to know about the user and their connection.
+type DropInfo struct {
+	Format        string // drop file format, e.g. "DOOR32.SYS"
+	Alias         string
+	RealName      string
+	TimeLeft      int // minutes
+	Emulation     int
+	Node          int
+	Baud          int
+	CommType      int
+	CommHandle    int // socket descriptor or COM port number
+	SecurityLevel int
+}
+
+// parser reads one drop file format from the lines of the file.
+type parser func(name string, lines []string) (*DropInfo, error)
+
+// format describes a drop file format and how to recognise its file name.
+type format struct {
+	match func(name string) bool
+	parse parser
+}
+
+// formats are checked in order; the first one found in the directory wins.
+var formats = []format{
+	{match: exactly("door32.sys"), parse: parseDoor32},
+	{match: exactly("door.sys"), parse: parseDoorSys},
+	{match: isDorinfo, parse: parseDorinfo},
+	{match: exactly("chain.txt"), parse: parseChainTxt},
+	{match: exactly("sfdoors.dat"), parse: parseSFDoors},
+}
+
+// Load reads the drop file at path. Path may name the drop file itself or the
+// directory the BBS wrote it to, in which case the format is detected from
+// the files present.
+func Load(path string) (*DropInfo, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fileInfo.IsDir() {
+		for _, f := range formats {
+			if f.match(filepath.Base(path)) {
+				return parseFile(path, f.parse)
+			}
+		}
+		return nil, fmt.Errorf("%s is not a supported drop file", path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range formats {
+		for _, entry := range entries {
+			if !entry.IsDir() && f.match(entry.Name()) {
+				return parseFile(filepath.Join(path, entry.Name()), f.parse)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no supported drop file found in %s", path)
+}
+
+// parseFile reads a drop file and hands its lines to the format's parser.
+func parseFile(path string, parse parser) (*DropInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, err
+	}
+	return parse(filepath.Base(path), lines)
+}
+
+// readLines returns the lines of a drop file. The scanner drops the CR of
+// CRLF line endings.
+func readLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Files saved by some editors start with a UTF-8 byte order mark
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff")
+	}
+	return lines, nil
+}
+
+// exactly matches a drop file name regardless of case.
+func exactly(name string) func(string) bool {
+	return func(s string) bool {
+		return strings.EqualFold(s, name)
+	}
+}
+
+// Errors wrapped by FieldError, for use with errors.Is.
+var (
+	ErrMissingField = errors.New("missing field")
+	ErrBadNumber    = errors.New("bad number")
+)
+
+// FieldError reports a drop file line that is missing or cannot be parsed.
+type FieldError struct {
+	Format string // drop file format, e.g. "DOOR32.SYS"
+	Line   int    // 1-based line number
+	Field  string // what the line should hold, e.g. "time left"
+	Value  string // the text found on the line, if any
+	Err    error  // ErrMissingField or ErrBadNumber
+}
+
+func (e *FieldError) Error() string {
+	if e.Err == ErrBadNumber {
+		return fmt.Sprintf("%s line %d (%s): %v %q", e.Format, e.Line, e.Field, e.Err, e.Value)
+	}
+	return fmt.Sprintf("%s line %d (%s): %v", e.Format, e.Line, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fields reads typed values from the lines of a drop file. The first error is
+// kept and later reads return zero values, so a parser can read every field
+// and check the error once at the end.
+type fields struct {
+	format string
+	lines  []string
+	err    error
+}
+
+func newFields(format string, lines []string) *fields {
+	return &fields{format: format, lines: lines}
+}
+
+// fail records the first error found in the file.
+func (f *fields) fail(n int, field, value string, err error) {
+	if f.err == nil {
+		f.err = &FieldError{Format: f.format, Line: n, Field: field, Value: value, Err: err}
+	}
+}
+
+// optional returns the given 1-based line, or "" if the file is too short.
+func (f *fields) optional(n int) string {
+	if n > len(f.lines) {
+		return ""
+	}
+	return strings.TrimSpace(f.lines[n-1])
+}
+
+// text returns the given 1-based line, which must be present.
+func (f *fields) text(n int, field string) string {
+	if f.err != nil {
+		return ""
+	}
+	if n > len(f.lines) {
+		f.fail(n, field, "", ErrMissingField)
+		return ""
+	}
+	return f.optional(n)
+}
+
+// name returns the given 1-based line, which must be present and not blank.
+func (f *fields) name(n int, field string) string {
+	s := f.text(n, field)
+	if f.err == nil && s == "" {
+		f.fail(n, field, "", ErrMissingField)
+	}
+	return s
+}
+
+// number returns the given 1-based line as an integer.
+func (f *fields) number(n int, field string) int {
+	s := f.text(n, field)
+	if f.err != nil {
+		return 0
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		f.fail(n, field, s, ErrBadNumber)
+		return 0
+	}
+	return v
+}
+
+// count returns the given 1-based line as an integer that cannot be negative.
+func (f *fields) count(n int, field string) int {
+	v := f.number(n, field)
+	if f.err == nil && v < 0 {
+		f.fail(n, field, f.optional(n), ErrBadNumber)
+	}
+	return v
+}
+
+// bounded returns the given 1-based line as an integer between lo and hi.
+func (f *fields) bounded(n int, field string, lo, hi int) int {
+	v := f.number(n, field)
+	if f.err == nil && (v < lo || v > hi) {
+		f.fail(n, field, f.optional(n), ErrBadNumber)
+	}
+	return v
+}
+
+// comPort returns the given 1-based line as a comm type and port number.
+// Ports are written as "COMn" or "COMn:", with COM0 meaning a local session.
+func (f *fields) comPort(n int, field string) (int, int) {
+	s := strings.TrimSuffix(strings.ToUpper(f.text(n, field)), ":")
+	if f.err != nil {
+		return 0, 0
+	}
+	port, err := strconv.Atoi(strings.TrimPrefix(s, "COM"))
+	if !strings.HasPrefix(s, "COM") || err != nil || port < 0 {
+		f.fail(n, field, s, ErrBadNumber)
+		return 0, 0
+	}
+	if port == 0 {
+		return CommLocal, 0
+	}
+	return CommSerial, port
+}
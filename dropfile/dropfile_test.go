@@ -0,0 +1,181 @@
+package dropfile
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSamples(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want DropInfo
+	}{
+		{"mystic", DropInfo{Format: "DOOR32.SYS", Alias: "robbiew", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationANSI, Node: 1, Baud: 38400, CommType: CommTelnet, CommHandle: 5, SecurityLevel: 255}},
+		{"talisman", DropInfo{Format: "DOOR32.SYS", Alias: "Starfox", RealName: "Jane Doe", TimeLeft: 45, Emulation: EmulationANSI, Node: 2, CommType: CommTelnet, CommHandle: 7, SecurityLevel: 20}},
+		{"gap31", DropInfo{Format: "DOOR.SYS", Alias: "Robbie Whiteman", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationANSI, Node: 3, Baud: 38400, CommType: CommSerial, CommHandle: 1, SecurityLevel: 255}},
+		{"gap52", DropInfo{Format: "DOOR.SYS", Alias: "robbiew", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationANSI, Node: 3, Baud: 38400, CommType: CommSerial, CommHandle: 1, SecurityLevel: 255}},
+		{"rbbs", DropInfo{Format: "DORINFO.DEF", Alias: "ROBBIE WHITEMAN", RealName: "ROBBIE WHITEMAN", TimeLeft: 60, Emulation: EmulationANSI, Node: 1, Baud: 38400, CommType: CommSerial, CommHandle: 1, SecurityLevel: 255}},
+		{"rbbs-local", DropInfo{Format: "DORINFO.DEF", Alias: "ROBBIE WHITEMAN", RealName: "ROBBIE WHITEMAN", TimeLeft: 60, Emulation: EmulationANSI, Node: 11, CommType: CommLocal, SecurityLevel: 255}},
+		{"wwiv", DropInfo{Format: "CHAIN.TXT", Alias: "ROBBIEW", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationANSI, Node: 2, Baud: 38400, CommType: CommSerial, CommHandle: 1, SecurityLevel: 255}},
+		{"wwiv-old", DropInfo{Format: "CHAIN.TXT", Alias: "ROBBIEW", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationANSI, Node: 1, Baud: 38400, CommType: CommSerial, CommHandle: 1, SecurityLevel: 255}},
+		{"spitfire", DropInfo{Format: "SFDOORS.DAT", Alias: "Robbie Whiteman", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationANSI, Node: 1, Baud: 38400, CommType: CommSerial, CommHandle: 1, SecurityLevel: 255}},
+		{"spitfire-local", DropInfo{Format: "SFDOORS.DAT", Alias: "Robbie Whiteman", RealName: "Robbie Whiteman", TimeLeft: 60, Emulation: EmulationASCII, Node: 1, CommType: CommLocal, SecurityLevel: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, err := Load(filepath.Join("testdata", tt.dir))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Load = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	got, err := Load(filepath.Join("testdata", "mystic", "DOOR32.SYS"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Alias != "robbiew" {
+		t.Errorf("Alias = %q, want %q", got.Alias, "robbiew")
+	}
+
+	if _, err := Load(filepath.Join("testdata", "mystic")); err != nil {
+		t.Errorf("Load of the directory: %v", err)
+	}
+	if _, err := Load("dropfile.go"); err == nil {
+		t.Error("Load of a file that is not a drop file succeeded")
+	}
+}
+
+func TestLoadTruncated(t *testing.T) {
+	tests := []struct {
+		dir  string
+		line int
+	}{
+		{"door32-truncated", 9},
+		{"doorsys-truncated", 19},
+		{"dorinfo-truncated", 11},
+		{"chaintxt-truncated", 16},
+		{"sfdoors-truncated", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			_, err := Load(filepath.Join("testdata", tt.dir))
+			checkFieldError(t, err, ErrMissingField, tt.line)
+		})
+	}
+}
+
+// sample returns the lines of a sample drop file with one line replaced.
+func sample(t *testing.T, path string, line int, text string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := readLines(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines[line-1] = text
+	return lines
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string // sample file to change
+		line  int    // line to replace
+		text  string
+		parse parser
+		err   error
+	}{
+		{"door32 comm type", "mystic/DOOR32.SYS", 1, "telnet", parseDoor32, ErrBadNumber},
+		{"door32 comm type range", "mystic/DOOR32.SYS", 1, "7", parseDoor32, ErrBadNumber},
+		{"door32 blank alias", "mystic/DOOR32.SYS", 7, "   ", parseDoor32, ErrMissingField},
+		{"door32 negative time", "mystic/DOOR32.SYS", 9, "-5", parseDoor32, ErrBadNumber},
+		{"door32 emulation", "mystic/DOOR32.SYS", 10, "9", parseDoor32, ErrBadNumber},
+		{"doorsys com port", "gap52/DOOR.SYS", 1, "LPT1:", parseDoorSys, ErrBadNumber},
+		{"doorsys blank name", "gap52/DOOR.SYS", 10, "", parseDoorSys, ErrMissingField},
+		{"doorsys node", "gap31/DOOR.SYS", 4, "one", parseDoorSys, ErrBadNumber},
+		{"dorinfo blank baud", "rbbs/DORINFO1.DEF", 5, "", parseDorinfo, ErrMissingField},
+		{"dorinfo baud", "rbbs/DORINFO1.DEF", 5, "FAST BAUD,N,8,1", parseDorinfo, ErrBadNumber},
+		{"dorinfo blank first name", "rbbs/DORINFO1.DEF", 7, " ", parseDorinfo, ErrMissingField},
+		{"chaintxt seconds", "wwiv/CHAIN.TXT", 16, "lots", parseChainTxt, ErrBadNumber},
+		{"chaintxt remote", "wwiv/CHAIN.TXT", 15, "2", parseChainTxt, ErrBadNumber},
+		{"sfdoors com port", "spitfire/SFDOORS.DAT", 6, "-1", parseSFDoors, ErrBadNumber},
+		{"sfdoors security", "spitfire/SFDOORS.DAT", 11, "high", parseSFDoors, ErrBadNumber},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := sample(t, tt.path, tt.line, tt.text)
+			_, err := tt.parse(filepath.Base(tt.path), lines)
+			checkFieldError(t, err, tt.err, tt.line)
+		})
+	}
+}
+
+// checkFieldError checks that err is a *FieldError for the given line that
+// wraps want.
+func checkFieldError(t *testing.T, err, want error, line int) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("error = %v, want %v", err, want)
+	}
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		t.Fatalf("error %v is not a *FieldError", err)
+	}
+	if fe.Line != line {
+		t.Errorf("FieldError.Line = %d, want %d", fe.Line, line)
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	lines, err := readLines(strings.NewReader("\ufefffirst  \r\nsecond\r\n\r\nlast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first  ", "second", "", "last"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("readLines = %q, want %q", lines, want)
+	}
+}
+
+// FuzzParse checks that no parser panics, whatever the drop file holds.
+func FuzzParse(f *testing.F) {
+	samples, _ := filepath.Glob(filepath.Join("testdata", "*", "*"))
+	for _, path := range samples {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	names := []string{"door32.sys", "door.sys", "dorinfo1.def", "dorinfoz.def", "chain.txt", "sfdoors.dat"}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		lines, err := readLines(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		for _, name := range names {
+			for _, format := range formats {
+				if format.match(name) {
+					info, err := format.parse(name, lines)
+					if (info == nil) == (err == nil) {
+						t.Errorf("%s: parse returned %v and %v", name, info, err)
+					}
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package dropfile
+
+import (
+	"strings"
+)
+
+// parseSFDoors reads a SFDOORS.DAT (Spitfire) file. Only the lines the door uses are read:
+//
+//	2  user's full name
+//	5  baud rate, 0 when local
+//	6  COM port, 0 when local
+//	7  time left in minutes
+//	10 ANSI ("TRUE" or "FALSE")
+//	11 security level
+//
+// The file has no node number, so node 1 is assumed.
+func parseSFDoors(name string, lines []string) (*DropInfo, error) {
+	f := newFields("SFDOORS.DAT", lines)
+	info := &DropInfo{Format: f.format, Node: 1}
+
+	info.RealName = f.name(2, "user name")
+	info.Alias = info.RealName
+	info.Baud = f.number(5, "baud rate")
+	if port := f.count(6, "COM port"); port != 0 {
+		info.CommType = CommSerial
+		info.CommHandle = port
+	}
+	info.TimeLeft = f.count(7, "time left")
+	if strings.EqualFold(f.text(10, "ANSI"), "TRUE") {
+		info.Emulation = EmulationANSI
+	}
+	info.SecurityLevel = f.number(11, "security level")
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return info, nil
+}
@@ -1,24 +1,63 @@
 package dropitem
 
-import (
-	"spacejunk3000/gear"
-	"spacejunk3000/weapon"
+import "strconv"
+
+// Kinds of item, see Item.Kind.
+const (
+	KindWeapon = "weapon"
+	KindGear   = "gear"
 )
 
-// Item represents any item in the game.
+// Item represents any item in the game: something a player can find, carry
+// in their inventory and use.
 type Item interface {
-	// Any methods common to all items can be declared here
-	ItemType() string
+	Name() string     // what the item is called
+	Slots() int       // inventory slots the item takes up
+	Kind() string     // KindWeapon or KindGear
+	Describe() string // a one-line summary for the player
+	Stats() []Stat    // the item's details, for the inventory screen
+	Row() Row         // the item's columns in the inventory list
+	Use(u User) bool  // uses the item, reporting whether it did anything
+}
+
+// Stat is one line of an item's details.
+type Stat struct {
+	Label        string
+	Text         string
+	Value        int  // for comparing with another item's stat of the same label
+	HigherBetter bool // whether a higher value is better
+}
+
+// NumStat returns a stat that is compared by its value.
+func NumStat(label string, value int, higherBetter bool) Stat {
+	return Stat{Label: label, Text: strconv.Itoa(value), Value: value, HigherBetter: higherBetter}
+}
+
+// TextStat returns a stat that is not compared.
+func TextStat(label, text string) Stat {
+	return Stat{Label: label, Text: text}
 }
 
-// WeaponWrapper wraps a weapon item.
-type WeaponWrapper struct {
-	Weapon *weapon.Weapon
-	Item   // Embed the Item interface
+// Row is an item's columns in the inventory list, after its name and weight.
+type Row struct {
+	Type  string
+	Ammo  string // rounds loaded or carried, or "-"
+	Fire  string // fire rate, or "-"
+	Alert bool   // the item needs attention, e.g. a jammed weapon
 }
 
-// GearWrapper wraps a gear item.
-type GearWrapper struct {
-	Gear *gear.Gear
-	Item // Embed the Item interface
+// User is what an item acts on when it is used. The game implements it for
+// the fight in progress.
+type User interface {
+	// Heal mends the player's wounds, reporting whether any were mended.
+	Heal(item Item, amount int) bool
+	// Shield shields the player from the given amount of damage until
+	// the end of the encounter, reporting whether it did.
+	Shield(item Item, amount int) bool
+	// Damage hits the enemy with the given type of damage, reporting
+	// whether the item was spent.
+	Damage(item Item, damageType string) bool
+	// Wield takes a weapon in the player's hand, reporting whether they
+	// switched to it.
+	Wield(item Item) bool
 }
@@ -5,8 +5,7 @@ import (
 	"math/rand"
 	"os"
 	"spacejunk3000/dropitem"
-	"spacejunk3000/gear"
-	"spacejunk3000/weapon"
+	"spacejunk3000/loot"
 )
 
 // Enemy represents the characteristics of a game enemy.
@@ -21,7 +20,8 @@ type Enemy struct {
 	EnemyExplDamage    int    `json:"enemyExplDamage"`
 	PlayerRangedDamage int    `json:"playerRangedDamage"`
 	PlayerCloseDamage  int    `json:"playerCloseDamage"`
-	ItemDrop           int    `json:"itemDrop"`
+	ItemDrop           int    `json:"itemDrop"`  // rolls on the loot table when defeated
+	LootTable          string `json:"lootTable"` // name of the enemy's loot table, see loot.Tables
 	Initiative         bool   `json:"initiative"`
 }
 
@@ -57,32 +57,8 @@ func LoadEnemies(filename string) ([]Enemy, error) {
 	return enemies, nil
 }
 
-// DropItems returns a single item dropped by the enemy.
-func (e *Enemy) DropItems() ([]dropitem.Item, error) {
-	// Randomly choose between dropping a weapon or gear
-	if rand.Intn(2) == 0 {
-		// Enemy drops a weapon
-		weapons, err := weapon.LoadWeapons("data/weapons.json")
-		if err != nil {
-			return nil, err
-		}
-		if len(weapons) > 0 {
-			randomIndex := rand.Intn(len(weapons))
-			// fmt.Println("Adding weapon:", weapons[randomIndex].Name) // Debug print
-			return []dropitem.Item{&dropitem.WeaponWrapper{Weapon: &weapons[randomIndex]}}, nil
-		}
-	} else {
-		// Enemy drops gear
-		gears, err := gear.LoadGear("data/gear.json")
-		if err != nil {
-			return nil, err
-		}
-		if len(gears) > 0 {
-			randomIndex := rand.Intn(len(gears))
-			// fmt.Println("Adding gear:", gears[randomIndex].Name) // Debug print
-			return []dropitem.Item{&dropitem.GearWrapper{Gear: &gears[randomIndex]}}, nil
-		}
-	}
-
-	return nil, nil // No items to drop
+// DropItems returns the items dropped by the enemy, from its loot table.
+// ItemDrop is the number of weighted rolls made on the table.
+func (e *Enemy) DropItems(tables *loot.Tables, rng *rand.Rand) []dropitem.Item {
+	return tables.Drop(e.LootTable, e.ItemDrop, rng)
 }
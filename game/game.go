@@ -1,42 +1,73 @@
 package game
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"math/rand"
+	"spacejunk3000/combat"
 	"spacejunk3000/door"
-	"spacejunk3000/dropitem"
 	"spacejunk3000/enemy"
 	"spacejunk3000/gear"
 	"spacejunk3000/implant"
+	"spacejunk3000/loot"
 	"spacejunk3000/player"
+	"spacejunk3000/store"
 	"spacejunk3000/weapon"
 	"strings"
 
 	"strconv"
 	"time"
-
-	"github.com/eiannone/keyboard"
 )
 
 type Game struct {
 	Player          *player.Player
+	Term            *door.Terminal // the user's terminal, all game output goes here
 	Enemies         []enemy.Enemy
 	Weapons         []weapon.Weapon
 	Gear            []gear.Gear
 	CurrentEnemy    enemy.Enemy
 	UsedHealthDrone bool // whether the health drone has been used in the current encounter
+	ImplantUses     int  // times the player's implant has been used in the current encounter
 	Implants        []implant.Implant
+	Loot            *loot.Tables // what defeated enemies drop
 	QuitGame        bool
+	CombatLog       []string   // most recent combat messages, shown beside the combat UI
+	Defeated        int        // enemies defeated this run
+	rng             *rand.Rand // source of randomness for dice rolls
 }
 
+// Number of crew dice rolled in each round of combat.
+const crewDicePerRound = 2
+
+// Wounds the health drone mends, once per encounter.
+const healthDroneHeal = 3
+
+// Size and position of the combat log panel.
+const (
+	logCol   = 42
+	logRow   = 10
+	logWidth = 38
+	logLines = 12
+)
+
 // InitializePlayer initializes a player by loading an existing one or creating a new one if not found.
-func InitializePlayer(playerName string, weapons []weapon.Weapon, implants []implant.Implant) (*player.Player, error) {
+// A player without a weapon is given one at random from rng.
+func InitializePlayer(t *door.Terminal, playerName string, weapons []weapon.Weapon, implants []implant.Implant, rng *rand.Rand) (*player.Player, error) {
 	// Load existing player or create a new one if not found
 	p, err := player.LoadPlayer(playerName)
-	if err != nil || p == nil {
-		charType := SelectCharacterType()                  // Let the user select a character type if creating a new player
-		selectedImplant := implant.SelectImplant(implants) // Select an implant
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		// A save that cannot be read must not be overwritten by a new player
+		return nil, fmt.Errorf("failed to load player: %v", err)
+	}
+	if p == nil {
+		charType, err := SelectCharacterType(t) // Let the user select a character type if creating a new player
+		if err != nil {
+			return nil, err
+		}
+		selectedImplant, err := implant.SelectImplant(t, implants) // Select an implant
+		if err != nil {
+			return nil, err
+		}
 
 		// Initialize the player with default values and selected implant
 		p, err = player.NewPlayer(playerName, charType, 0, 0, 0)
@@ -48,12 +79,12 @@ func InitializePlayer(playerName string, weapons []weapon.Weapon, implants []imp
 		p.Implant = selectedImplant
 
 		// Randomly select a weapon for the player
-		source := rand.NewSource(time.Now().UnixNano())
-		random := rand.New(source)
-		randomIndex := random.Intn(len(weapons))
+		randomIndex := rng.Intn(len(weapons))
 
-		// Equip the randomly selected weapon to the player
-		if err := p.EquipWeapon(&weapons[randomIndex]); err != nil {
+		// Equip a copy of the randomly selected weapon to the player, so
+		// firing it does not change the catalog
+		w := weapons[randomIndex]
+		if err := p.EquipItem(&w); err != nil {
 			return nil, fmt.Errorf("failed to equip weapon: %v", err)
 		}
 
@@ -65,14 +96,14 @@ func InitializePlayer(playerName string, weapons []weapon.Weapon, implants []imp
 	} else if !p.Alive { // Check if the player is starting over due to death
 		player.ResetPlayer(p)
 		player.SavePlayer(p)
-	} else if p.Weapons == nil { // Check if the player does not have a weapon equipped
+	} else if len(p.Weapons()) == 0 { // Check if the player does not have a weapon equipped
 		// Randomly select a weapon for the player
-		source := rand.NewSource(time.Now().UnixNano())
-		random := rand.New(source)
-		randomIndex := random.Intn(len(weapons))
+		randomIndex := rng.Intn(len(weapons))
 
-		// Equip the randomly selected weapon to the player
-		if err := p.EquipWeapon(&weapons[randomIndex]); err != nil {
+		// Equip a copy of the randomly selected weapon to the player, so
+		// firing it does not change the catalog
+		w := weapons[randomIndex]
+		if err := p.EquipItem(&w); err != nil {
 			return nil, fmt.Errorf("failed to equip weapon: %v", err)
 		}
 
@@ -85,204 +116,313 @@ func InitializePlayer(playerName string, weapons []weapon.Weapon, implants []imp
 	return p, nil
 }
 
-func NewGame(playerName string, charType player.CharacterType, weapons []weapon.Weapon, implants []implant.Implant, enemies []enemy.Enemy) (*Game, error) {
+// NewGame creates a game for the named player. Its dice and loot rolls, and
+// the player's starting weapon, come from the given seed, so a session can
+// be repeated, e.g. for testing; a seed of 0 picks one at random.
+func NewGame(t *door.Terminal, playerName string, charType player.CharacterType, weapons []weapon.Weapon, gears []gear.Gear, implants []implant.Implant, enemies []enemy.Enemy, tables *loot.Tables, seed int64) (*Game, error) {
+	random := newRNG(seed)
+
 	// Initialize the player
-	p, err := InitializePlayer(playerName, weapons, implants)
+	p, err := InitializePlayer(t, playerName, weapons, implants, random)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize player: %v", err)
+		return nil, fmt.Errorf("failed to initialize player: %w", err)
 	}
 
-	// Randomly select a location and enemy
-	source := rand.NewSource(time.Now().UnixNano())
-	random := rand.New(source)
-	randomEnemyIndex := random.Intn(len(enemies))
-	selectedEnemy := enemies[randomEnemyIndex]
+	// Saves keep the implant as it was when chosen, so pick up any changes
+	// to its definition
+	if imp, ok := implant.Find(implants, p.Implant.Name); ok {
+		p.Implant = imp
+	}
+
+	// The same goes for weapons, and those saved before the catalog gave
+	// reliability would otherwise use the default
+	for _, w := range p.Weapons() {
+		w.Refresh(weapons)
+	}
 
 	// Create the Game instance
 	game := &Game{
-		Player:       p,
-		Enemies:      enemies,
-		Weapons:      weapons,
-		CurrentEnemy: selectedEnemy,
-		QuitGame:     false,
+		Player:   p,
+		Term:     t,
+		Enemies:  enemies,
+		Weapons:  weapons,
+		Gear:     gears,
+		Implants: implants,
+		Loot:     tables,
+		QuitGame: false,
+		rng:      random,
 	}
 
 	return game, nil
 }
 
+// newRNG returns a source of randomness for the given seed, or for a random
+// one if seed is 0.
+func newRNG(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
 // StartGame initializes and starts the game.
-func StartGame(playerName string, weapons []weapon.Weapon, implants []implant.Implant) (*player.Player, error) {
+func StartGame(t *door.Terminal, playerName string, weapons []weapon.Weapon, implants []implant.Implant) (*player.Player, error) {
 	// Initialize the player
-	p, err := InitializePlayer(playerName, weapons, implants)
+	p, err := InitializePlayer(t, playerName, weapons, implants, newRNG(0))
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize player: %v", err)
+		return nil, fmt.Errorf("failed to initialize player: %w", err)
 	}
 
 	return p, nil
 }
 
-func SelectCharacterType() player.CharacterType {
-	door.ClearScreenAndDisplay("assets/selectCrew.ans")
+// SelectCharacterType shows the crew selection screen and waits for a choice.
+func SelectCharacterType(t *door.Terminal) (player.CharacterType, error) {
+	t.ClearScreenAndDisplay("assets/selectCrew.ans")
 
 	for {
-		input, err := door.GetKeyboardInput()
+		input, err := t.GetKeyboardInput()
 		if err != nil {
-			fmt.Println("Error reading keyboard input:", err)
-			continue
+			return "", err
 		}
 
 		switch input {
 		case "1":
-			return player.Pirate
+			return player.Pirate, nil
 		case "2":
-			return player.Marine
+			return player.Marine, nil
 		case "3":
-			return player.Empath
+			return player.Empath, nil
 		case "4":
-			return player.Spy
+			return player.Spy, nil
 		case "5":
-			return player.Scientist
+			return player.Scientist, nil
 		case "6":
-			return player.Smuggler
+			return player.Smuggler, nil
 		default:
-			door.HandleInvalidInput()
+			t.HandleInvalidInput()
 		}
 	}
 }
 
-func printStatSymbol(value int, symbol int) {
+func printStatSymbol(t *door.Terminal, value int, symbol int) {
 	for i := 0; i < value; i++ {
-		fmt.Printf("%c", symbol)
+		t.Printf("%c", symbol)
 	}
-	fmt.Print(" ") // Add a space after the symbols
+	t.Print(" ") // Add a space after the symbols
 }
 
 // Function to display the combat UI.
-func CombatUI(g *Game) {
+func (g *Game) CombatUI() {
 	// Get the player's character type and convert to string
 	charType := strings.ToLower(fmt.Sprintf("%v", g.Player.Type))
 	charName := g.Player.Name
-	door.ClearScreen()
+	g.Term.ClearScreen()
 
 	width := 39
 
-	// Convert player's health to string
+	// Convert player's health to string, with any shield on top
 	health := strconv.Itoa(g.Player.Health)
+	if g.Player.Shield > 0 {
+		health += fmt.Sprintf("+%d", g.Player.Shield)
+	}
 	// Print player's health
 	alignedText := door.RightAlignText(health, width, door.YellowHi, door.BgYellow)
-	fmt.Println(alignedText, door.Reset)
+	g.Term.Println(alignedText, door.Reset)
 
 	// Print players name
-	door.MoveCursor(2, 1)
-	fmt.Printf("%s%s%s - %s%s", door.BgYellow, door.WhiteHi, charName, charType, door.Reset)
+	g.Term.MoveCursor(2, 1)
+	g.Term.Printf("%s%s%s - %s%s", door.BgYellow, door.WhiteHi, charName, charType, door.Reset)
 
 	// Print heart symbol
-	door.MoveCursor(33, 1)
-	fmt.Printf("%s%s[%sM%s%s%s] %s%s%c%s", door.BgYellow, door.Cyan, door.YellowHi, door.Reset, door.BgYellow, door.Cyan, door.BgYellow, door.RedHi, 3, door.Reset)
+	g.Term.MoveCursor(33, 1)
+	g.Term.Printf("%s%s[%sM%s%s%s] %s%s%c%s", door.BgYellow, door.Cyan, door.YellowHi, door.Reset, door.BgYellow, door.Cyan, door.BgYellow, door.RedHi, 3, door.Reset)
 
 	// Print background color block
-	door.PrintColoredBlock(11, 30, 1, 7, door.BgCyan)
+	g.Term.PrintColoredBlock(11, 30, 1, 7, door.BgCyan)
 
 	// Print player's character type image
-	door.PrintAnsiLoc("assets/"+charType+".ans", 1, 2)
+	g.Term.PrintAnsiLoc("assets/"+charType+".ans", 1, 2)
 
 	// Print Player stats
-	door.MoveCursor(13, 3)
-	fmt.Printf("%s%s", door.BgCyan, door.Red)
-	fmt.Print("str ")
-	printStatSymbol(g.Player.Stats.Strength, 6) // Spade symbol
-	fmt.Printf("%s%s", door.BgCyan, door.CyanHi)
-	fmt.Print(" dex ")
-	printStatSymbol(g.Player.Stats.Dexterity, 4) // diamond symbol
-	fmt.Printf("%s%s", door.BgCyan, door.YellowHi)
-	fmt.Print(" int ")
-	printStatSymbol(g.Player.Stats.Intelligence, 15) // Star symbol
-	fmt.Printf("%s", door.Reset)
+	g.Term.MoveCursor(13, 3)
+	g.Term.Printf("%s%s", door.BgCyan, door.Red)
+	g.Term.Print("str ")
+	printStatSymbol(g.Term, g.Player.Stats.Strength, 6) // Spade symbol
+	g.Term.Printf("%s%s", door.BgCyan, door.CyanHi)
+	g.Term.Print(" dex ")
+	printStatSymbol(g.Term, g.Player.Stats.Dexterity, 4) // diamond symbol
+	g.Term.Printf("%s%s", door.BgCyan, door.YellowHi)
+	g.Term.Print(" int ")
+	printStatSymbol(g.Term, g.Player.Stats.Intelligence, 15) // Star symbol
+	g.Term.Printf("%s", door.Reset)
 
 	// Medical Record & Implant
-	// door.MoveCursor(13, 5)
-	// fmt.Printf("%s%s[%sM%s%s%s] Medical Record%s", door.BgCyan, door.Yellow, door.YellowHi, door.Reset, door.BgCyan, door.Yellow, door.Reset)
-
-	door.MoveCursor(13, 5)
-	fmt.Printf("%s%sImplants: %s%s %s", door.BgCyan, door.Yellow, door.YellowHi, g.Player.Implant.Name, door.Reset)
+	// g.Term.MoveCursor(13, 5)
+	// g.Term.Printf("%s%s[%sM%s%s%s] Medical Record%s", door.BgCyan, door.Yellow, door.YellowHi, door.Reset, door.BgCyan, door.Yellow, door.Reset)
+
+	g.Term.MoveCursor(13, 5)
+	g.Term.Printf("%s%sImplants: %s%s %s", door.BgCyan, door.Yellow, door.YellowHi, g.Player.Implant.Name, door.Reset)
+
+	// Warn the user when their BBS time is running out
+	if left := g.Term.TimeLeft(); left >= 0 && left <= time.Minute {
+		g.Term.MoveCursor(13, 7)
+		g.Term.Printf("%s%sTime left: under 1 minute! %s", door.BgCyan, door.RedHi, door.Reset)
+	} else if left >= 0 && left <= 5*time.Minute {
+		g.Term.MoveCursor(13, 7)
+		g.Term.Printf("%s%sTime left: %d minutes %s", door.BgCyan, door.YellowHi, int((left+time.Minute-1)/time.Minute), door.Reset)
+	}
 
 	// Max carry weight
-	door.MoveCursor(13, 6)
-	fmt.Printf("%s%sCarry Wt: %s%d%s%s%s/%s%d %s", door.BgCyan, door.Yellow, door.YellowHi, g.Player.GearSlots+g.Player.WeaponSlots, door.Reset, door.BgCyan, door.Yellow, door.YellowHi, g.Player.MaxSlots, door.Reset)
+	g.Term.MoveCursor(13, 6)
+	g.Term.Printf("%s%sCarry Wt: %s%d%s%s%s/%s%d %s", door.BgCyan, door.Yellow, door.YellowHi, g.Player.UsedSlots(), door.Reset, door.BgCyan, door.Yellow, door.YellowHi, g.Player.Inventory.Capacity, door.Reset)
 
 	// Weapons & Gear
-	door.MoveCursor(1, 9)
-	fmt.Printf("%s%s# Name           Wt Type      Ammo Fire %s", door.BgYellow, door.YellowHi, door.Reset)
-	door.MoveCursor(1, 10)
-	player.PrintPlayerInventory(g.Player)
+	g.Term.MoveCursor(1, 9)
+	g.Term.Printf("%s%s# Name           Wt Type      Ammo Fire %s", door.BgYellow, door.YellowHi, door.Reset)
+	g.Term.MoveCursor(1, 10)
+	player.PrintPlayerInventory(g.Term, g.Player)
+
+	// Enemy and combat log
+	g.printEnemy()
+	g.printCombatLog()
+}
+
+// printEnemy shows the current enemy and the symbols still needed to defeat it.
+func (g *Game) printEnemy() {
+	e := g.CurrentEnemy
 
+	g.Term.MoveCursor(logCol, 1)
+	g.Term.Printf("%s%s%-*s%s", door.BgRed, door.WhiteHi, logWidth, " "+e.Name, door.Reset)
+
+	g.Term.MoveCursor(logCol, 3)
+	g.Term.Printf("%sstr ", door.Red)
+	printStatSymbol(g.Term, e.StrDie, 6) // Spade symbol
+	g.Term.Printf("%s dex ", door.CyanHi)
+	printStatSymbol(g.Term, e.DexDie, 4) // diamond symbol
+	g.Term.Printf("%s int ", door.YellowHi)
+	printStatSymbol(g.Term, e.IntDie, 15) // Star symbol
+	g.Term.Print(door.Reset)
+
+	g.Term.MoveCursor(logCol, 5)
+	g.Term.Printf("%sDamage: %sranged %s%d %sclose %s%d%s", door.Cyan, door.BlackHi, door.RedHi, e.PlayerRangedDamage, door.BlackHi, door.RedHi, e.PlayerCloseDamage, door.Reset)
+}
+
+// printCombatLog shows the most recent combat messages in the log panel.
+func (g *Game) printCombatLog() {
+	g.Term.MoveCursor(logCol, logRow-1)
+	g.Term.Printf("%s%s%-*s%s", door.BgYellow, door.YellowHi, logWidth, " Combat Log", door.Reset)
+
+	start := 0
+	if len(g.CombatLog) > logLines {
+		start = len(g.CombatLog) - logLines
+	}
+	for i, line := range g.CombatLog[start:] {
+		g.Term.MoveCursor(logCol, logRow+i)
+		g.Term.Printf("%s%s%s", door.Cyan, line, door.Reset)
+	}
+}
+
+// logf adds a message to the combat log, wrapping it to the width of the log panel.
+func (g *Game) logf(format string, args ...interface{}) {
+	g.CombatLog = append(g.CombatLog, wrap(fmt.Sprintf(format, args...), logWidth)...)
+
+	// Keep only what fits in the panel
+	if len(g.CombatLog) > logLines {
+		g.CombatLog = g.CombatLog[len(g.CombatLog)-logLines:]
+	}
+}
+
+// wrap splits text into lines of at most width characters, breaking between
+// words.
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
 }
 
 // Function to present the user with combat options.
-func PresentCombatOptions(g *Game) {
-	// fmt.Printf("You've encountered an enemy: %s\r\n", g.CurrentEnemy.Name)
+func (g *Game) PresentCombatOptions() {
+	// g.Term.Printf("You've encountered an enemy: %s\r\n", g.CurrentEnemy.Name)
 
-	door.MoveCursor(1, 15)
+	g.Term.MoveCursor(1, 15)
 	title := door.CenterAlignText("Combat Options", 39, door.CyanHi, door.BgBlack)
-	fmt.Printf("%s%s", title, door.Reset)
+	g.Term.Printf("%s%s", title, door.Reset)
 
-	door.MoveCursor(1, 17)
+	g.Term.MoveCursor(1, 16)
 
-	fmt.Printf("%s[%sQ%s%s] %sQuit %s(death) %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.BlackHi, door.Reset)
-	fmt.Printf("%s[%sG%s%s] %sUse Gear %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
+	g.Term.Printf("%s[%sQ%s%s] %sQuit %s(death) %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.BlackHi, door.Reset)
+	g.Term.Printf("%s[%sG%s%s] %sUse Gear  %s[%sI%s%s] %sInventory %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
 	if !g.UsedHealthDrone {
-		fmt.Printf("%s[%sH%s%s] %sHealth Drone %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
+		g.Term.Printf("%s[%sH%s%s] %sHealth Drone %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
 	} else {
-		fmt.Printf("%s[H] Health Drone unavailable %s\r\n", door.BlackHi, door.Reset)
+		g.Term.Printf("%s[H] Health Drone unavailable %s\r\n", door.BlackHi, door.Reset)
+	}
+	if blade := g.blade(); blade != nil {
+		g.Term.Printf("%s[%sF%s%s] %sFight with %s %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, blade.Name(), door.Reset)
+	} else {
+		g.Term.Printf("%s[%sF%s%s] %sFight Unarmed %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
 	}
-	fmt.Printf("%s[%sF%s%s] %sFight Hand to Hand %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
 
-	// Check if the player has a ranged weapon
-	for _, w := range g.Player.Weapons {
-		if w.WeaponTypeName == "Ranged" {
-			fmt.Printf("%s[%sS%s%s] %sShoot %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
-			fmt.Printf("%s[%sR%s%s] %sReload %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
-			break
-		}
+	// Shoot and reload share a line to leave room for the other options
+	if w := g.rangedWeapon(); w != nil {
+		g.Term.Printf("%s[%sS%s%s] %sShoot %s ", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, w.Name())
+	}
+	if g.hasRangedWeapon() {
+		g.Term.Printf("%s[%sR%s%s] %sReload %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.Reset)
 	}
+	if n := len(g.Player.Weapons()); n > 1 {
+		g.Term.Printf("%s[%sW%s%s] %sSwitch Weapon %s(or 1-%d) %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, door.BlackHi, n, door.Reset)
+	}
+	if g.jammedWeapon() != nil {
+		g.Term.Printf("%s[%sJ%s%s] %sClear Jam %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.RedHi, door.Reset)
+	}
+	g.printImplantOption()
 }
 
 // Function to handle an encounter.
-func HandleEncounter(g *Game) {
+func (g *Game) HandleEncounter() error {
 
 	// Print player information
-	fmt.Printf("Name: %s\r\n", g.Player.Name)
-	fmt.Printf("Health: %d\r\n", g.Player.Health)
-
-	// Display the health record using the DisplayHealthRecord method
-	// fmt.Printf("%s", g.Player.DisplayHealthRecord())
+	g.Term.Printf("Name: %s\r\n", g.Player.Name)
+	g.Term.Printf("Health: %d\r\n", g.Player.Health)
 
 	// Show available implants
-	fmt.Println("\r\nImplants:")
+	g.Term.Println("\r\nImplants:")
 	if g.Player.Implant.Name != "" {
-		fmt.Printf("- %s\r\n", g.Player.Implant.Name)
+		g.Term.Printf("- %s\r\n", g.Player.Implant.Name)
 	} else {
-		fmt.Println("- No implants equipped")
+		g.Term.Println("- No implants equipped")
 	}
 
 	// Show available weapons and their ammo
-	fmt.Println("\r\nWeapons:")
-	for _, w := range g.Player.Weapons {
+	g.Term.Println("\r\nWeapons:")
+	for _, w := range g.Player.Weapons() {
 		// Check if the weapon is of type "Ranged"
 		if w.WeaponTypeName == "Ranged" {
-			fmt.Printf("- %s (Ammo: %d)\r\n", w.Name, w.Ammo)
+			g.Term.Printf("- %s (Ammo: %d)\r\n", w.Name(), w.Ammo)
 		} else {
-			fmt.Printf("- %s\r\n", w.Name)
+			g.Term.Printf("- %s\r\n", w.Name())
 		}
 	}
 
 	// Print player's equipped gear
-	fmt.Println("Equipped Gear:")
-	if len(g.Gear) == 0 {
-		fmt.Println("- None")
+	g.Term.Println("Equipped Gear:")
+	if len(g.Player.Gear()) == 0 {
+		g.Term.Println("- None")
 	} else {
-		for _, g := range g.Gear {
-			fmt.Printf("- %s\r\n", g.Name)
+		for _, item := range g.Player.Gear() {
+			g.Term.Printf("- %s\r\n", item.Name())
 		}
 	}
 
@@ -290,26 +430,32 @@ func HandleEncounter(g *Game) {
 	for {
 
 		// Display the combat UI
-		CombatUI(g)
+		g.CombatUI()
 		// Present combat options
-		PresentCombatOptions(g)
+		g.PresentCombatOptions()
 
 		// Handle user choice
-		HandleCombatChoice(g)
+		if err := g.HandleCombatChoice(); err != nil {
+			return err
+		}
+
+		// Save the run after every turn so it cannot be undone by hanging up
+		if err := SaveRun(g); err != nil {
+			g.logf("Error saving run state: %v", err)
+		}
 
 		// Check if the player is dead or chooses to quit
 		if g.Player.Health <= 0 {
-			fmt.Println("\r\nGame Over! You are dead.")
-			return
+			g.Term.Println("\r\nGame Over! You are dead.")
+			return nil
 		}
 
 		// Check if the player chooses to quit
 		if g.QuitGame {
 			// Prompt for playing again
-			choice, err := door.PromptYesNo("\r\nQuitting will end the game. Quit now?")
+			choice, err := g.Term.PromptYesNo("\r\nQuitting will end the game. Quit now?")
 			if err != nil {
-				log.Println("Error reading keyboard input:", err)
-				break
+				return err
 			}
 
 			// Check if the user wants to quit
@@ -318,276 +464,485 @@ func HandleEncounter(g *Game) {
 				continue
 			}
 			if choice == "y" || choice == "Y" {
-				break
+				return nil
 			} else {
-				fmt.Println("\r\nInvalid choice. Please enter 'y' or 'n'.")
+				g.Term.Println("\r\nInvalid choice. Please enter 'y' or 'n'.")
 				continue
 			}
 
 		}
 
 		// Check if the enemy is dead
-		// if g.CurrentEnemy.Health <= 0 {
-		//  fmt.Printf("You defeated the %s!\r\n", g.CurrentEnemy.Name)
-		//  return
-		// }
+		if g.CurrentEnemy.Name == "" {
+			return nil
+		}
 	}
 }
 
 // HandleCombatChoice handles user's combat choice including selecting an implant if needed.
-func HandleCombatChoice(g *Game) {
+// A read error, such as the session timing out, is returned to the caller.
+func (g *Game) HandleCombatChoice() error {
 	g.QuitGame = false
 	for {
 
-		char, _, err := keyboard.GetSingleKey()
+		char, err := g.Term.ReadKey()
 		if err != nil {
-			fmt.Println("Error reading keyboard input:", err)
-			continue // Continue to loop for valid input
+			return err
 		}
 
 		switch char {
 		case 'F', 'f':
-			// Hand to hand combat logic
-			fmt.Printf("You chose hand to hand combat with %s\r\n", g.CurrentEnemy.Name)
-			items, err := g.CurrentEnemy.DropItems()
-			if err != nil {
-				// Handle error
-				fmt.Println("Error dropping items:", err)
-				return
-			}
-			fmt.Printf("Dropped %d items:\r\n", len(items)) // Print the number of dropped items
-			// Iterate over the dropped items and print thssem
-			for _, item := range items {
-				fmt.Println(item) // Print the dropped item
-				fmt.Println("\r\nDo you want to pick up this item? (Y/N)")
-				choice, _, err := keyboard.GetSingleKey()
-				if err != nil {
-					fmt.Println("Error reading keyboard input:", err)
-					continue // Continue to loop for valid input
-				}
-				switch choice {
-				case 'Y', 'y':
-					// Check the underlying type of item
-					switch item := item.(type) {
-					case *dropitem.WeaponWrapper:
-						// Handle weapon
-						weapon := item.Weapon
-						weaponType := weapon.WeaponType()
-						fmt.Printf("\r\nWeapon type: %s\r\n", weaponType)
-						// Perform other actions specific to weapons
-					case *dropitem.GearWrapper:
-						// Handle gear
-						gear := item.Gear
-						gearType := gear.GearType()
-						fmt.Printf("\r\nGear type: %s\r\n", gearType)
-						if err := g.Player.EquipGear(gear); err != nil {
-							fmt.Println("Error equipping gear:", err)
-							// Handle error (e.g., inform the player)
-						} else {
-							fmt.Println("\r\nGear equipped successfully:", gear.Name)
-						}
-					default:
-						// Handle unknown item type
-						fmt.Println("\r\nUnknown item type:", item)
-					}
-				}
+			// Close combat, with a blade if the player has one
+			if blade := g.blade(); blade != nil {
+				g.logf("You close in on the %s with your %s.", g.CurrentEnemy.Name, blade.Name())
+			} else {
+				g.logf("You engage the %s unarmed.", g.CurrentEnemy.Name)
 			}
+			return g.FightRound(combat.Close)
 
 		case 'Q', 'q':
 			// Quit the game
 			g.QuitGame = true
-			return // Exit the function, effectively ending the game loop
+			return nil // Exit the function, effectively ending the game loop
 
 		case 'G', 'g':
-			// Gear logic
-			fmt.Println("You chose to use gear.")
+			// Using gear takes the turn
+			return g.UseGear()
 		case 'R', 'r':
-			// Reload logic
-			fmt.Println("You chose to reload.")
+			// Reloading takes the turn
+			return g.Reload()
+		case 'J', 'j':
+			// Clearing a jam takes the turn
+			return g.ClearJam()
 		case 'C', 'c':
-			// Use implant logic
-			fmt.Println("You chose to use an implant.")
-			// Check if the player has an implant
-			if g.Player.Implant.Name != "" {
-				// If the player has an implant, perform actions with it
-				fmt.Printf("You selected %s implant.\r\n", g.Player.Implant.Name)
-				// Perform actions with the selected implant if needed
-			} else {
-				fmt.Println("You don't have any implants.")
-			}
+			// Implants used by choice do not take the turn
+			return g.UseImplant()
 		case 'H', 'h':
-			if !g.UsedHealthDrone {
-				// Activate Health Drone logic here
-				fmt.Println("Activating Health Drone.")
-				// Update player health here
-				g.UsedHealthDrone = true // Mark the drone as used
-			} else {
-				fmt.Println("Health Drone is unavailable.")
-			}
+			g.UseHealthDrone()
+		case 'M', 'm':
+			// Looking at the medical record does not take the turn
+			return g.MedicalRecord()
 		case 'S', 's':
 			// Ranged combat logic
-			fmt.Println("You chose to shoot.")
-			ShootWithRangedWeapon(g)
+			return g.ShootWithRangedWeapon()
+		case 'W', 'w':
+			return g.ChooseWeapon()
+		case 'I', 'i':
+			// Sorting through the inventory does not take the turn
+			_, err := g.ManageInventory(nil)
+			return err
+		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			// Quick swap to the weapon in that inventory row
+			if i := int(char - '1'); i < len(g.Player.Weapons()) {
+				return g.SwitchWeapon(i)
+			}
+			g.Term.HandleInvalidInput()
+			continue
 		default:
-			fmt.Println("Invalid choice. Please select a valid option.")
+			g.Term.HandleInvalidInput()
 			continue // Continue to loop for valid input
 		}
 
-		if g.Player.Health <= 0 {
-			fmt.Println("Game Over! You are dead.")
-			break
-		}
-		if g.QuitGame {
-			break
-		}
+		// Any valid action ends the turn so the combat UI can be redrawn
+		return nil
 	}
 }
 
-// At the start of each new encounter, you need to reset the UsedHealthDrone field
-func StartNewEncounter(g *Game) {
-	// Reset the health drone availability for the new encounter
-	g.UsedHealthDrone = false
+// FightRound resolves one round of crew dice combat against the current enemy
+// and applies the outcome to the game.
+func (g *Game) FightRound(mode combat.Mode) error {
+	e := &g.CurrentEnemy
 
-	// Declare quitGame variable
-	g.QuitGame = false
+	// Close combat dice come from the player's build and weapon
+	dice := crewDicePerRound
+	if mode == combat.Close {
+		dice = combat.CloseDice(g.Player.Stats.Strength, g.Player.Stats.Dexterity, g.blade() != nil)
+	}
+	if effect, ok := g.implantReady(implant.TriggerAttack, mode); ok && effect.Action == implant.ActionExtraBlow {
+		dice++
+		g.useImplant(1)
+		g.logf("Your %s strikes another blow.", g.Player.Implant.Name)
+	}
 
-	// Continue with encounter setup...
-	HandleEncounter(g)
+	result, err := combat.Resolve(combat.State{
+		Mode:         mode,
+		Faces:        g.Player.CrewDice.Faces(),
+		Dice:         dice,
+		Remaining:    g.requirements(),
+		RangedDamage: e.PlayerRangedDamage,
+		CloseDamage:  e.PlayerCloseDamage,
+	}, g.rng)
+	if err != nil {
+		g.logf("Combat error: %v", err)
+		return nil
+	}
+
+	// The enemy keeps whatever requirements are left for the next round
+	e.StrDie = result.Remaining.Strength
+	e.DexDie = result.Remaining.Dexterity
+	e.IntDie = result.Remaining.Intelligence
+
+	rolls := make([]string, len(result.Rolls))
+	for i, face := range result.Rolls {
+		rolls[i] = face.String()
+	}
+	g.logf("You rolled %s.", strings.Join(rolls, ", "))
+	if matched := result.Matched.Total(); matched > 0 {
+		g.logf("%d symbol(s) matched.", matched)
+	} else {
+		g.logf("Nothing matched.")
+	}
+
+	if result.Defeated {
+		return g.DefeatEnemy()
+	}
+
+	g.takeDamage(result.Damage)
+	return nil
 }
 
-// Function to get user's choice.
-func GetUserChoice(g *Game) int {
-	// Initialize keyboard listener
-	err := keyboard.Open()
+// enemyAttack gives the current enemy its attack when the player's turn
+// rolled no crew dice: they spent it on something other than fighting, or
+// fired a volley, which the ammo die resolves on its own.
+func (g *Game) enemyAttack(mode combat.Mode) error {
+	e := &g.CurrentEnemy
+
+	// A round with no dice rolled can only fail
+	result, err := combat.Resolve(combat.State{
+		Mode:         mode,
+		Faces:        g.Player.CrewDice.Faces(),
+		Remaining:    g.requirements(),
+		RangedDamage: e.PlayerRangedDamage,
+		CloseDamage:  e.PlayerCloseDamage,
+	}, g.rng)
 	if err != nil {
-		panic(err)
+		g.logf("Combat error: %v", err)
+		return nil
 	}
-	defer keyboard.Close()
 
-	// Loop until a valid choice is made
-	for {
-		char, _, err := keyboard.GetSingleKey()
-		if err != nil {
-			panic(err)
-		}
+	g.takeDamage(result.Damage)
+	return nil
+}
 
-		// Convert the pressed key to integer if possible
-		choice, err := strconv.Atoi(string(char))
-		if err == nil && choice >= 1 && choice <= 6 {
-			return choice // Return valid choice
-		}
+// requirements returns what the current enemy still requires to be defeated.
+func (g *Game) requirements() combat.Requirements {
+	return combat.Requirements{
+		Strength:     g.CurrentEnemy.StrDie,
+		Dexterity:    g.CurrentEnemy.DexDie,
+		Intelligence: g.CurrentEnemy.IntDie,
+	}
+}
 
-		fmt.Println("Invalid choice. Please select a valid option.")
-		PresentCombatOptions(g) // Present combat options again
+// takeDamage applies damage dealt by the current enemy to the player.
+func (g *Game) takeDamage(damage int) {
+	if damage <= 0 {
+		return
+	}
+	change := g.Player.Wound(damage, g.CurrentEnemy.Name)
+	if change.Shielded > 0 {
+		g.logf("Your shield absorbs %d damage from the %s.", change.Shielded, g.CurrentEnemy.Name)
+	}
+	if change.Wounds > 0 {
+		g.logf("The %s hits you for %d damage!", g.CurrentEnemy.Name, change.Wounds)
+	}
+	if change.Died {
+		g.logf("The %s has killed you.", g.CurrentEnemy.Name)
+	}
+	if err := player.SavePlayer(g.Player); err != nil {
+		g.logf("Error saving player data: %v", err)
 	}
 }
 
-// ShootWithRangedWeapon simulates shooting with a ranged weapon.
-func ShootWithRangedWeapon(g *Game) {
-	// Check if the player has a ranged weapon
-	hasRangedWeapon := false
-	for _, w := range g.Player.Weapons {
-		if w.WeaponTypeName == "Ranged" {
-			hasRangedWeapon = true
+// DefeatEnemy handles the current enemy being defeated: it offers the enemy's
+// loot and removes the enemy from the remaining enemies.
+func (g *Game) DefeatEnemy() error {
+	defeated := g.CurrentEnemy
+	g.logf("You defeated the %s!", defeated.Name)
+
+	// Remove the enemy from the remaining enemies
+	for i, e := range g.Enemies {
+		if e.Name == defeated.Name {
+			g.Enemies = append(g.Enemies[:i], g.Enemies[i+1:]...)
 			break
 		}
 	}
-	if !hasRangedWeapon {
-		fmt.Println("You do not have a ranged weapon.")
-		return
+	g.CurrentEnemy = enemy.Enemy{}
+	g.Defeated++
+
+	// Save before the loot screen so hanging up there cannot bring the enemy back
+	if err := SaveRun(g); err != nil {
+		g.logf("Error saving run state: %v", err)
 	}
 
-	// Select the ranged weapon to use if the player has multiple
-	var selectedWeapon *weapon.Weapon
-	if len(g.Player.Weapons) > 1 {
-		// Implement logic to let the player select a weapon
-		// For simplicity, we'll just select the first ranged weapon
-		for _, w := range g.Player.Weapons {
-			if w.WeaponTypeName == "Ranged" {
-				selectedWeapon = w
-				break
-			}
+	g.Term.ClearScreen()
+	g.Term.Printf("You defeated the %s!\r\n\r\n", defeated.Name)
+	return g.HandleLoot(&defeated)
+}
+
+// HandleLoot offers the player each item dropped by a defeated enemy on the
+// inventory screen, where they can make room for it.
+func (g *Game) HandleLoot(e *enemy.Enemy) error {
+	items := e.DropItems(g.Loot, g.rng)
+	if len(items) == 0 {
+		g.Term.Printf("The %s dropped nothing.\r\n", e.Name)
+		g.Term.Print("\r\nPress any key to continue...")
+		return g.Term.WaitForAnyKey()
+	}
+
+	for _, item := range items {
+		if _, err := g.ManageInventory(item); err != nil {
+			return err
 		}
-	} else {
-		selectedWeapon = g.Player.Weapons[0]
 	}
+	return nil
+}
 
-	// Check if the player has enough ammo for the required fire rate of the selected weapon
-	fmt.Printf("Selected weapon: %s, Ammo: %d, Fire Rate: %d \r\n", selectedWeapon.Name, selectedWeapon.Ammo, selectedWeapon.FireRate)
-	if selectedWeapon.Ammo < selectedWeapon.FireRate {
-		fmt.Println("You do not have enough ammo for this weapon.")
-		return
+// StartNewEncounter picks the next enemy, unless an encounter is still in
+// progress, and plays the encounter out.
+func (g *Game) StartNewEncounter() error {
+	// Declare quitGame variable
+	g.QuitGame = false
+
+	// Pick the next enemy if the last one was defeated
+	if g.CurrentEnemy.Name == "" {
+		if len(g.Enemies) == 0 {
+			return nil
+		}
+		g.CurrentEnemy = g.Enemies[g.rng.Intn(len(g.Enemies))]
+
+		// Reset the health drone, implant and shield for the new encounter
+		g.UsedHealthDrone = false
+		g.ImplantUses = 0
+		g.Player.ClearShield()
+
+		g.CombatLog = nil
+		g.logf("%s", g.CurrentEnemy.Desc)
+
+		if err := SaveRun(g); err != nil {
+			g.logf("Error saving run state: %v", err)
+		}
 	}
 
-	// Select the fire rate
-	// For simplicity, we'll assume the fire rate is always 1
-	fireRate := 1
+	// Continue with encounter setup...
+	return g.HandleEncounter()
+}
 
-	// Fire the weapon and deplete the ammo
-	selectedWeapon.Ammo -= fireRate
+// ShootWithRangedWeapon fires a volley from the ranged weapon in the player's
+// hand at the current enemy: the player picks how many rounds to fire, each
+// round rolls the ammo die and hits deal damage by the enemy's profile for the
+// weapon's ammo. The volley is the player's whole attack: if the enemy
+// survives, it attacks back.
+func (g *Game) ShootWithRangedWeapon() error {
+	selectedWeapon := g.rangedWeapon()
+	if selectedWeapon == nil {
+		g.logf("You do not have a ranged weapon in hand.")
+		return nil
+	}
+	if selectedWeapon.Jammed {
+		g.logf("Your %s is jammed. Clear it before firing.", selectedWeapon.Name())
+		return nil
+	}
+	if selectedWeapon.Ammo < 1 {
+		g.logf("Your %s is out of ammo.", selectedWeapon.Name())
+		return nil
+	}
 
-	// Save the player's updated data after firing
-	if err := player.SavePlayer(g.Player); err != nil {
-		fmt.Printf("Error saving player data: %v\r\n", err)
-	}
-
-	// Roll ammo dice for each ammo fired
-	// For simplicity, we'll just simulate the dice roll without actual dice mechanics
-	// We'll use a random number generator to simulate the dice roll
-	ammoHits := make(map[string]int)
-	for i := 0; i < fireRate; i++ {
-		// Simulate dice roll for each ammo type
-		// For simplicity, we'll just assume hits for now
-		ammoType := selectedWeapon.AmmoType
-		hit := simulateHit()
-		if hit {
-			fmt.Println("Shot hit!")
-			ammoHits[ammoType]++
-			applyRandomVulnerabilityReduction(&g.Enemies[0], ammoType)
-		} else {
-			fmt.Println("Shot missed.")
+	ammo, err := combat.ParseAmmo(selectedWeapon.AmmoType)
+	if err != nil {
+		g.logf("Your %s cannot fire: %v", selectedWeapon.Name(), err)
+		return nil
+	}
+
+	// Fire up to the weapon's fire rate, as far as the ammo goes
+	rounds, err := g.chooseRounds(min(max(selectedWeapon.FireRate, 1), selectedWeapon.Ammo))
+	if err != nil || rounds == 0 {
+		return err
+	}
+
+	// Implants can add a free round or retake a missed shot
+	volley := rounds
+	if effect, ok := g.implantReady(implant.TriggerAttack, combat.Ranged); ok && effect.Action == implant.ActionExtraShot {
+		volley++
+		g.useImplant(1)
+		g.logf("Your %s lines up an extra shot.", g.Player.Implant.Name)
+	}
+	rerolls := 0
+	if effect, ok := g.implantReady(implant.TriggerMiss, combat.Ranged); ok && effect.Action == implant.ActionRerollShot {
+		rerolls = g.implantUsesLeft()
+		if rerolls < 0 {
+			rerolls = volley
 		}
 	}
 
-	// Apply ammo hits to enemies
-	for _, enemy := range g.Enemies {
-		// Check if the enemy is within range
-		// For simplicity, we'll assume all enemies are within range
-		// Apply damage based on ammo hits and enemy's vulnerabilities
-		switch selectedWeapon.AmmoType {
-		case "Energy":
-			enemy.StrDie -= ammoHits["Energy"] * enemy.EnemyEnerDamage
-		case "Ballistic":
-			enemy.StrDie -= ammoHits["Ballistic"] * enemy.EnemyBallDamage
-		case "Explosive":
-			enemy.StrDie -= ammoHits["Explosive"] * enemy.EnemyExplDamage
+	e := &g.CurrentEnemy
+	result, err := combat.Fire(combat.Volley{
+		Ammo:   ammo,
+		Rounds: volley,
+		Profile: combat.Profile{
+			Ballistic: e.EnemyBallDamage,
+			Energy:    e.EnemyEnerDamage,
+			Explosive: e.EnemyExplDamage,
+		},
+		Remaining:   g.requirements(),
+		Reliability: selectedWeapon.EffectiveReliability(),
+		Rerolls:     rerolls,
+	}, g.rng)
+	if err != nil {
+		g.logf("Combat error: %v", err)
+		return nil
+	}
+
+	// Only the rounds actually fired are spent, and the implant's extra one is free
+	selectedWeapon.Ammo -= min(len(result.Shots), rounds)
+	g.useImplant(result.Rerolls)
+	selectedWeapon.Jammed = result.Jammed
+	e.StrDie = result.Remaining.Strength
+	e.DexDie = result.Remaining.Dexterity
+	e.IntDie = result.Remaining.Intelligence
+
+	g.logf("You fire your %s at the %s.", selectedWeapon.Name(), e.Name)
+	for i, shot := range result.Shots {
+		if shot.Retaken {
+			g.logf("Shot %d missed; your %s takes it again.", i+1, g.Player.Implant.Name)
 		}
+		switch {
+		case shot.Outcome == combat.Miss:
+			g.logf("Shot %d: rolled %d, miss.", i+1, shot.Roll)
+		case shot.Damage == 0:
+			g.logf("Shot %d: rolled %d, %s with no effect.", i+1, shot.Roll, shot.Outcome)
+		default:
+			g.logf("Shot %d: rolled %d, %s for %d %s damage.", i+1, shot.Roll, shot.Outcome, shot.Damage, ammo)
+		}
+		if shot.Jammed {
+			g.logf("Your %s jams!", selectedWeapon.Name())
+		}
+	}
+
+	// Save the player's updated data after firing
+	if err := player.SavePlayer(g.Player); err != nil {
+		g.logf("Error saving player data: %v", err)
+	}
+
+	if result.Defeated {
+		return g.DefeatEnemy()
+	}
+
+	// The volley replaces the crew dice, so only the enemy's attack is left
+	return g.enemyAttack(combat.Ranged)
+}
+
+// chooseRounds asks how many rounds to fire, from 1 to most. It returns 0 if
+// the player changes their mind.
+func (g *Game) chooseRounds(most int) (int, error) {
+	if most <= 1 {
+		return most, nil
 	}
 
-	// Continue combat logic...
+	g.Term.PrintStringLoc(fmt.Sprintf("%sFire how many rounds? %s(1-%d, Esc to cancel) %s", door.Cyan, door.BlackHi, most, door.Reset), 1, 24)
+	for {
+		char, err := g.Term.ReadKey()
+		if err != nil {
+			return 0, err
+		}
+		if char == 27 {
+			return 0, nil
+		}
+		if rounds, err := strconv.Atoi(string(char)); err == nil && rounds >= 1 && rounds <= most {
+			return rounds, nil
+		}
+	}
 }
 
-// applyRandomVulnerabilityReduction reduces one of the enemy's vulnerabilities corresponding to the player's ammo type.
-func applyRandomVulnerabilityReduction(enemy *enemy.Enemy, ammoType string) {
-	// Determine which vulnerability to reduce based on the ammo type
-	switch ammoType {
-	case "Energy":
-		if enemy.StrDie > 0 {
-			enemy.StrDie--
+// Reload loads a ranged weapon from the ammo the player carries, up to the
+// weapon's capacity, starting with the weapon in hand. Reloading takes the
+// player's turn, so the enemy gets a free attack. A jammed weapon has to be
+// cleared before it can be reloaded.
+func (g *Game) Reload() error {
+	weapons := g.Player.Weapons()
+	if w := g.rangedWeapon(); w != nil {
+		weapons = append([]*weapon.Weapon{w}, weapons...)
+	}
+
+	var jammed, noAmmo *weapon.Weapon
+	for _, w := range weapons {
+		if w.WeaponTypeName != "Ranged" || w.Ammo >= w.AmmoCapacity {
+			continue
 		}
-	case "Ballistic":
-		if enemy.DexDie > 0 {
-			enemy.DexDie--
+		ammo := g.findAmmo(w.AmmoType)
+		if ammo == nil {
+			noAmmo = w
+			continue
+		}
+		if w.Jammed {
+			jammed = w
+			continue
 		}
-	case "Explosive":
-		if enemy.IntDie > 0 {
-			enemy.IntDie--
+
+		rounds := min(ammo.Rounds, w.AmmoCapacity-w.Ammo)
+		w.Ammo += rounds
+		ammo.Rounds -= rounds
+		g.logf("You load %d round(s) into your %s (%d/%d).", rounds, w.Name(), w.Ammo, w.AmmoCapacity)
+
+		// Spent ammo no longer takes up a slot
+		if ammo.Rounds <= 0 {
+			g.logf("That was your last %s.", ammo.Name())
+			if err := g.Player.RemoveItem(ammo); err != nil {
+				g.logf("Error saving player data: %v", err)
+			}
+		} else if err := player.SavePlayer(g.Player); err != nil {
+			g.logf("Error saving player data: %v", err)
+		}
+
+		return g.enemyAttack(combat.Ranged)
+	}
+
+	switch {
+	case jammed != nil:
+		g.logf("Your %s is jammed. Clear it before reloading.", jammed.Name())
+	case noAmmo != nil:
+		g.logf("You have no %s ammo for your %s.", strings.ToLower(noAmmo.AmmoType), noAmmo.Name())
+	default:
+		g.logf("You have nothing to reload.")
+	}
+	return nil
+}
+
+// findAmmo returns the ammo the player carries for the given ammo type, or
+// nil if they have none.
+func (g *Game) findAmmo(ammoType string) *gear.Gear {
+	for _, item := range g.Player.Gear() {
+		if item.GearTypeName == gear.Ammo && strings.EqualFold(item.AmmoType, ammoType) && item.Rounds > 0 {
+			return item
 		}
 	}
+	return nil
 }
 
-// simulateHit simulates whether a shot hits or misses.
-func simulateHit() bool {
-	rand.Seed(time.Now().UnixNano())
-	return rand.Intn(2) == 0 // 50% chance of hit or miss
+// ClearJam clears the player's jammed weapon. It takes the player's turn, so
+// the enemy gets a free attack.
+func (g *Game) ClearJam() error {
+	w := g.jammedWeapon()
+	if w == nil {
+		g.logf("None of your weapons are jammed.")
+		return nil
+	}
+
+	w.Jammed = false
+	g.logf("You clear the jam in your %s.", w.Name())
+	if err := player.SavePlayer(g.Player); err != nil {
+		g.logf("Error saving player data: %v", err)
+	}
+
+	return g.enemyAttack(combat.Ranged)
+}
+
+// jammedWeapon returns the first of the player's weapons that is jammed, or
+// nil if none are.
+func (g *Game) jammedWeapon() *weapon.Weapon {
+	for _, w := range g.Player.Weapons() {
+		if w.Jammed {
+			return w
+		}
+	}
+	return nil
 }
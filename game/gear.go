@@ -0,0 +1,148 @@
+package game
+
+import (
+	"fmt"
+	"spacejunk3000/combat"
+	"spacejunk3000/door"
+	"spacejunk3000/dropitem"
+	"spacejunk3000/gear"
+	"spacejunk3000/player"
+	"strconv"
+)
+
+// itemUser is what the player's items act on during combat, see
+// dropitem.Item.Use. Each use reports whether the item did anything, so a
+// single-use item is only consumed if it did.
+type itemUser struct {
+	g *Game
+}
+
+// UseGear shows the player's usable gear in place of the combat options and
+// uses the one they pick. Using gear takes the player's turn, so if the enemy
+// survives it gets a free attack.
+func (g *Game) UseGear() error {
+	var usable []*gear.Gear
+	for _, item := range g.Player.Gear() {
+		if item.Effect() != "" {
+			usable = append(usable, item)
+		}
+	}
+	if len(usable) == 0 {
+		g.logf("You have no gear you can use.")
+		return nil
+	}
+
+	item, err := g.chooseGear(usable)
+	if err != nil || item == nil {
+		return err
+	}
+
+	if !item.Use(itemUser{g}) {
+		return nil
+	}
+
+	// Spent gear no longer takes up a slot
+	if item.SingleUse {
+		if err := g.Player.RemoveItem(item); err != nil {
+			g.logf("Error saving player data: %v", err)
+		}
+	} else if err := player.SavePlayer(g.Player); err != nil {
+		g.logf("Error saving player data: %v", err)
+	}
+
+	if g.requirements().Met() {
+		return g.DefeatEnemy()
+	}
+	return g.enemyAttack(combat.Ranged)
+}
+
+// chooseGear lists the given gear over the combat options and waits for the
+// player to pick one. It returns nil if they change their mind.
+func (g *Game) chooseGear(items []*gear.Gear) (*gear.Gear, error) {
+	for row := 15; row <= 24; row++ {
+		g.Term.PrintStringLoc(fmt.Sprintf("%-40s", ""), 1, row)
+	}
+	title := door.CenterAlignText("Use Gear", 39, door.CyanHi, door.BgBlack)
+	g.Term.PrintStringLoc(title+door.Reset, 1, 15)
+
+	g.Term.MoveCursor(1, 17)
+	for i, item := range items {
+		detail := ""
+		switch item.Effect() {
+		case gear.EffectHeal:
+			detail = fmt.Sprintf("heals %d", item.Heal)
+		case gear.EffectShield:
+			detail = fmt.Sprintf("shields %d", item.Shield)
+		case gear.EffectDamage:
+			detail = item.DamageType + " damage"
+		}
+		g.Term.Printf("%s[%s%d%s%s] %s%s %s(%s)%s\r\n", door.BlackHi, door.CyanHi, i+1, door.Reset, door.BlackHi, door.Cyan, item.Name(), door.BlackHi, detail, door.Reset)
+	}
+	g.Term.PrintStringLoc(fmt.Sprintf("%sWhich item? %s(Esc to cancel) %s", door.Cyan, door.BlackHi, door.Reset), 1, 24)
+
+	for {
+		char, err := g.Term.ReadKey()
+		if err != nil {
+			return nil, err
+		}
+		if char == 27 {
+			return nil, nil
+		}
+		if n, err := strconv.Atoi(string(char)); err == nil && n >= 1 && n <= len(items) {
+			return items[n-1], nil
+		}
+	}
+}
+
+// Heal mends the player's wounds.
+func (u itemUser) Heal(item dropitem.Item, amount int) bool {
+	g := u.g
+	change := g.Player.AdjustHealth(amount)
+	if change.Healed == 0 {
+		g.logf("You have no wounds for the %s to mend.", item.Name())
+		return false
+	}
+	g.logf("The %s mends %d wound(s).", item.Name(), change.Healed)
+	return true
+}
+
+// Shield shields the player until the end of the encounter.
+func (u itemUser) Shield(item dropitem.Item, amount int) bool {
+	g := u.g
+	if amount <= 0 {
+		return false
+	}
+	g.Player.AddShield(amount)
+	g.logf("The %s shields you from the next %d damage.", item.Name(), amount)
+	return true
+}
+
+// Damage hits the current enemy with the given damage type, taking symbols
+// off what it requires by its damage profile, as a shot would.
+func (u itemUser) Damage(item dropitem.Item, damageType string) bool {
+	g := u.g
+	ammo, err := combat.ParseAmmo(damageType)
+	if err != nil {
+		g.logf("Your %s cannot be used: %v", item.Name(), err)
+		return false
+	}
+
+	e := &g.CurrentEnemy
+	profile := combat.Profile{
+		Ballistic: e.EnemyBallDamage,
+		Energy:    e.EnemyEnerDamage,
+		Explosive: e.EnemyExplDamage,
+	}
+	remaining := g.requirements()
+	taken := remaining.Take(profile.Damage(ammo))
+	e.StrDie = remaining.Strength
+	e.DexDie = remaining.Dexterity
+	e.IntDie = remaining.Intelligence
+
+	if n := taken.Total(); n > 0 {
+		g.logf("Your %s hits the %s for %d %s damage.", item.Name(), e.Name, n, ammo)
+	} else {
+		g.logf("Your %s has no effect on the %s.", item.Name(), e.Name)
+	}
+	return true
+}
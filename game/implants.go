@@ -0,0 +1,114 @@
+package game
+
+import (
+	"fmt"
+	"spacejunk3000/combat"
+	"spacejunk3000/door"
+	"spacejunk3000/implant"
+)
+
+// implantReady returns the player's implant effect if it applies on the given
+// trigger in the given combat mode and has uses left this encounter.
+func (g *Game) implantReady(trigger string, mode combat.Mode) (implant.Effect, bool) {
+	effect := g.Player.Implant.Effect
+	if !effect.Applies(trigger, mode.String()) || g.implantUsesLeft() == 0 {
+		return implant.Effect{}, false
+	}
+	return effect, true
+}
+
+// implantUsesLeft returns how many more times the player's implant can be used
+// this encounter, or -1 if there is no limit.
+func (g *Game) implantUsesLeft() int {
+	uses := g.Player.Implant.Effect.Uses
+	if uses <= 0 {
+		return -1
+	}
+	return max(uses-g.ImplantUses, 0)
+}
+
+// useImplant records n uses of the player's implant this encounter.
+func (g *Game) useImplant(n int) {
+	g.ImplantUses += n
+}
+
+// printImplantOption shows the implant option if the player's implant is
+// used by choice.
+func (g *Game) printImplantOption() {
+	effect := g.Player.Implant.Effect
+	if effect.Trigger != implant.TriggerManual || effect.Action == "" {
+		return
+	}
+
+	n := g.implantUsesLeft()
+	if n == 0 {
+		g.Term.Printf("%s[C] %s unavailable %s\r\n", door.BlackHi, g.Player.Implant.Name, door.Reset)
+	} else {
+		left := ""
+		if n > 0 {
+			left = fmt.Sprintf(" (%d left)", n)
+		}
+		g.Term.Printf("%s[%sC%s%s] %s%s%s %s\r\n", door.BlackHi, door.CyanHi, door.Reset, door.BlackHi, door.Cyan, g.Player.Implant.Name, left, door.Reset)
+	}
+}
+
+// UseImplant activates the player's implant if it is used by choice. Using
+// an implant does not take the player's turn.
+func (g *Game) UseImplant() error {
+	imp := g.Player.Implant
+	if imp.Name == "" {
+		g.logf("You don't have any implants.")
+		return nil
+	}
+	if imp.Effect.Action == "" {
+		g.logf("Your %s is no use in a fight.", imp.Name)
+		return nil
+	}
+	if imp.Effect.Trigger != implant.TriggerManual {
+		g.logf("Your %s works on its own.", imp.Name)
+		return nil
+	}
+
+	mode := combat.Close
+	if imp.Effect.Applies(implant.TriggerManual, combat.Ranged.String()) {
+		mode = combat.Ranged
+	}
+	effect, ok := g.implantReady(implant.TriggerManual, mode)
+	if !ok {
+		g.logf("Your %s is spent for this encounter.", imp.Name)
+		return nil
+	}
+
+	switch effect.Action {
+	case implant.ActionWeakPoint:
+		if mode == combat.Ranged && g.rangedWeapon() == nil {
+			g.logf("Your %s needs a ranged weapon in hand to aim.", imp.Name)
+			return nil
+		}
+		e := &g.CurrentEnemy
+		remaining := g.requirements()
+		taken := remaining.Take(max(effect.Amount, 1))
+		e.StrDie = remaining.Strength
+		e.DexDie = remaining.Dexterity
+		e.IntDie = remaining.Intelligence
+		g.useImplant(1)
+		g.logf("Your %s finds a weak point in the %s: %d symbol(s) removed.", imp.Name, e.Name, taken.Total())
+
+		if remaining.Met() {
+			return g.DefeatEnemy()
+		}
+	default:
+		g.logf("Your %s does nothing.", imp.Name)
+	}
+	return nil
+}
+
+// hasRangedWeapon reports whether the player carries a ranged weapon.
+func (g *Game) hasRangedWeapon() bool {
+	for _, w := range g.Player.Weapons() {
+		if w.WeaponTypeName == "Ranged" {
+			return true
+		}
+	}
+	return false
+}
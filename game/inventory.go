@@ -0,0 +1,208 @@
+package game
+
+import (
+	"fmt"
+	"spacejunk3000/door"
+	"spacejunk3000/dropitem"
+	"spacejunk3000/player"
+	"strconv"
+)
+
+// Layout of the inventory screen.
+const (
+	invListRow    = 3  // the inventory rows, as in the combat UI
+	invCompareRow = 10 // the selected item beside the found one
+	invLeftCol    = 2
+	invRightCol   = 42
+	invHelpRow    = 23
+	invStatusRow  = 24
+)
+
+// inventoryScreen is the state of the inventory screen while it is open.
+type inventoryScreen struct {
+	g        *Game
+	selected int           // row of the inventory being inspected, from 0
+	found    dropitem.Item // an item the player can take, or nil
+	status   string        // the result of the last action
+}
+
+// ManageInventory opens the inventory screen, where the player can inspect
+// and drop what they carry. If found is not nil, it is an item they can take,
+// or swap for something they carry, and is compared with what they have
+// selected: at first the weapon in their hand. It reports whether the found
+// item was taken.
+func (g *Game) ManageInventory(found dropitem.Item) (bool, error) {
+	s := &inventoryScreen{g: g, selected: g.Player.ActiveWeapon, found: found}
+	if found != nil {
+		s.status = fmt.Sprintf("Found: %s.", found.Describe())
+	}
+
+	for {
+		s.draw()
+
+		char, err := g.Term.ReadKey()
+		if err != nil {
+			return false, err
+		}
+		switch char {
+		case 27, 'Q', 'q':
+			return false, nil
+		case 'D', 'd':
+			if err := s.drop(); err != nil {
+				return false, err
+			}
+		case 'S', 's':
+			if s.found != nil && s.swap() {
+				return true, nil
+			}
+		case 'T', 't':
+			if s.found != nil && s.take() {
+				return true, nil
+			}
+		default:
+			if n, err := strconv.Atoi(string(char)); err == nil && s.item(n-1) != nil {
+				s.selected = n - 1
+				s.status = ""
+			}
+		}
+	}
+}
+
+// item returns the item in row i of the inventory, weapons first as
+// player.PrintPlayerInventory lists them, or nil if the row is empty.
+func (s *inventoryScreen) item(i int) dropitem.Item {
+	items := s.g.Player.Inventory.Items()
+	if i < 0 || i >= len(items) {
+		return nil
+	}
+	return items[i]
+}
+
+// draw shows the whole screen.
+func (s *inventoryScreen) draw() {
+	t := s.g.Term
+	p := s.g.Player
+	t.ClearScreen()
+
+	t.PrintStringLoc(fmt.Sprintf("%s%s%-66s%s", door.BgYellow, door.WhiteHi, " Inventory", door.Reset), 1, 1)
+	t.PrintStringLoc(fmt.Sprintf("%s%sCarry Wt: %d/%d %s", door.BgYellow, door.YellowHi, p.UsedSlots(), p.Inventory.Capacity, door.Reset), 67, 1)
+
+	t.PrintStringLoc(fmt.Sprintf("%s%s# Name           Wt Type      Ammo Fire %s", door.BgYellow, door.YellowHi, door.Reset), 1, invListRow)
+	t.MoveCursor(1, invListRow+1)
+	player.PrintPlayerInventory(t, p)
+
+	// The selected item beside the found one
+	selected := s.item(s.selected)
+	if selected != nil {
+		label := "Selected:"
+		if w := p.CurrentWeapon(); w != nil && selected == dropitem.Item(w) {
+			label = "In hand:"
+		}
+		t.PrintStringLoc(fmt.Sprintf("%s%s%s", door.Cyan, label, door.Reset), invLeftCol, invCompareRow)
+		s.printDetails(selected, s.found, invLeftCol)
+	}
+	if s.found != nil {
+		t.PrintStringLoc(fmt.Sprintf("%sFound:%s", door.YellowHi, door.Reset), invRightCol, invCompareRow)
+		s.printDetails(s.found, selected, invRightCol)
+	}
+
+	help := "[1-9] Inspect  [D] Drop  "
+	if s.found != nil {
+		help += "[T] Take  [S] Swap for found  [Q] Leave it"
+	} else {
+		help += "[Q] Done"
+	}
+	t.PrintStringLoc(fmt.Sprintf("%s%s%s", door.BlackHi, help, door.Reset), 1, invHelpRow)
+	if s.status != "" {
+		t.PrintStringLoc(fmt.Sprintf("%s%s%s", door.YellowHi, s.status, door.Reset), 1, invStatusRow)
+	}
+}
+
+// printDetails lists an item's stats in a column of the screen. Stats that
+// are better than those of the other item are highlighted, so two weapons
+// can be compared at a glance.
+func (s *inventoryScreen) printDetails(item, other dropitem.Item, col int) {
+	mine := item.Stats()
+	theirs := map[string]int{}
+	if other != nil {
+		for _, st := range other.Stats() {
+			theirs[st.Label] = st.Value
+		}
+	}
+
+	row := invCompareRow + 1
+	s.g.Term.PrintStringLoc(fmt.Sprintf("%s%s%s", door.CyanHi, item.Name(), door.Reset), col, row)
+	for _, st := range mine {
+		row++
+		color := door.Cyan
+		if v, ok := theirs[st.Label]; ok && st.Value != v {
+			if (st.Value > v) == st.HigherBetter {
+				color = door.GreenHi
+			} else {
+				color = door.Red
+			}
+		}
+		s.g.Term.PrintStringLoc(fmt.Sprintf("%s%-12s%s%s%s", door.Cyan, st.Label, color, st.Text, door.Reset), col, row)
+	}
+}
+
+// drop asks whether to drop the selected item, and drops it.
+func (s *inventoryScreen) drop() error {
+	item := s.item(s.selected)
+	if item == nil {
+		s.status = "Select an item to drop first."
+		return nil
+	}
+
+	s.g.Term.PrintStringLoc(fmt.Sprintf("%sDrop your %s? (Y/N) %s", door.YellowHi, item.Name(), door.Reset), 1, invStatusRow)
+	char, err := s.g.Term.ReadKey()
+	if err != nil {
+		return err
+	}
+	if char != 'Y' && char != 'y' {
+		s.status = ""
+		return nil
+	}
+
+	if err := s.g.Player.RemoveItem(item); err != nil {
+		s.status = fmt.Sprintf("Error dropping item: %v", err)
+		return nil
+	}
+	s.status = fmt.Sprintf("You drop your %s.", item.Name())
+	s.selected = s.g.Player.ActiveWeapon
+	return nil
+}
+
+// take picks up the found item if there is room for it.
+func (s *inventoryScreen) take() bool {
+	p := s.g.Player
+	if !p.Inventory.Fits(s.found, nil) {
+		s.status = "No room: drop or swap something first."
+		return false
+	}
+	if err := s.g.Player.EquipItem(s.found); err != nil {
+		s.status = fmt.Sprintf("Error taking item: %v", err)
+		return false
+	}
+	return true
+}
+
+// swap leaves the selected item behind and takes the found one in its place.
+func (s *inventoryScreen) swap() bool {
+	p := s.g.Player
+	item := s.item(s.selected)
+	if item == nil {
+		s.status = "Select an item to swap first."
+		return false
+	}
+	if !p.Inventory.Fits(s.found, item) {
+		s.status = fmt.Sprintf("Still no room for the %s without your %s.", s.found.Name(), item.Name())
+		return false
+	}
+
+	if err := p.SwapItem(item, s.found); err != nil {
+		s.status = fmt.Sprintf("Error swapping item: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"fmt"
+	"spacejunk3000/door"
+	"spacejunk3000/player"
+	"strings"
+)
+
+// Layout of the medical record screen, drawn over assets/medical-layout.ans.
+const (
+	medRecordCol   = 2 // the medical record panel
+	medRecordRow   = 5
+	medRecordWidth = 39
+	medRecordLines = 10
+	medBoxRows     = 6 // boxes are listed in two columns of this many
+
+	medLogCol   = 45 // the combat log panel
+	medLogRow   = 9
+	medLogWidth = 34
+	medLogLines = 15
+)
+
+// UseHealthDrone mends some of the player's wounds. The drone can be used
+// once per encounter.
+func (g *Game) UseHealthDrone() {
+	if g.UsedHealthDrone {
+		g.logf("Health Drone is unavailable.")
+		return
+	}
+	g.UsedHealthDrone = true
+
+	if change := g.Player.AdjustHealth(healthDroneHeal); change.Healed > 0 {
+		g.logf("The Health Drone mends %d wound(s).", change.Healed)
+	} else {
+		g.logf("The Health Drone finds nothing to mend.")
+	}
+
+	if err := player.SavePlayer(g.Player); err != nil {
+		g.logf("Error saving player data: %v", err)
+	}
+}
+
+// MedicalRecord shows the player's medical record, with what caused each
+// wound, until a key is pressed.
+func (g *Game) MedicalRecord() error {
+	g.Term.ClearScreenAndDisplay("assets/medical-layout.ans")
+
+	// Replace the names and numbers in the art with the real ones
+	title := strings.ToUpper(fmt.Sprintf("%s (%s)", g.Player.Name, g.Player.Type))
+	g.Term.PrintStringLoc(fmt.Sprintf("%s%s%-30.30s%s", door.BgMagenta, door.WhiteHi, title, door.Reset), 12, 1)
+	e := g.CurrentEnemy
+	g.Term.PrintStringLoc(fmt.Sprintf("%s%-27.27s%s", door.WhiteHi, strings.ToUpper(e.Name), door.Reset), 53, 1)
+	for i, damage := range []int{e.EnemyBallDamage, e.EnemyEnerDamage, e.EnemyExplDamage} {
+		g.Term.PrintStringLoc(fmt.Sprintf("%s%d%s", door.CyanHi, damage, door.Reset), 79, 4+i)
+	}
+
+	g.printMedicalRecord()
+
+	// The combat log, rewrapped to fit the narrower panel
+	var lines []string
+	for _, line := range g.CombatLog {
+		lines = append(lines, wrap(line, medLogWidth)...)
+	}
+	if len(lines) > medLogLines {
+		lines = lines[len(lines)-medLogLines:]
+	}
+	for i := 0; i < medLogLines; i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		g.Term.PrintStringLoc(fmt.Sprintf("%s%-*s%s", door.Cyan, medLogWidth, line, door.Reset), medLogCol, medLogRow+i)
+	}
+
+	g.Term.PrintStringLoc(fmt.Sprintf("%sPress any key to return...%s", door.BlackHi, door.Reset), 11, 24)
+	return g.Term.WaitForAnyKey()
+}
+
+// printMedicalRecord fills the medical record panel: the player's health, then
+// each box of the health record with the last thing that wounded it.
+func (g *Game) printMedicalRecord() {
+	p := g.Player
+	blank := strings.Repeat(" ", medRecordWidth)
+	for i := 0; i < medRecordLines; i++ {
+		g.Term.PrintStringLoc(blank, medRecordCol, medRecordRow+i)
+	}
+
+	g.Term.PrintStringLoc(fmt.Sprintf("%sHealth %s%d%s/%d%s", door.Cyan, door.YellowHi, p.Health, door.Cyan, p.MaxHealth(), door.Reset), medRecordCol+1, medRecordRow)
+
+	cellWidth := medRecordWidth / 2
+	for i, box := range p.HealthRecord {
+		color := door.Green
+		switch box {
+		case player.BoxWounded:
+			color = door.RedHi
+		case player.BoxHealed:
+			color = door.Yellow
+		}
+
+		source := ""
+		if i < len(p.WoundSources) && len(p.WoundSources[i]) > 0 {
+			source = p.WoundSources[i][len(p.WoundSources[i])-1]
+		}
+
+		col := medRecordCol + 1 + (i/medBoxRows)*cellWidth
+		row := medRecordRow + 1 + i%medBoxRows
+		g.Term.PrintStringLoc(fmt.Sprintf("%s%2d %s%s %s%-*.*s%s", door.BlackHi, i+1, color, box, door.Cyan, cellWidth-6, cellWidth-6, source, door.Reset), col, row)
+	}
+
+	g.Term.PrintStringLoc(fmt.Sprintf("%s%s healthy  %s%s wounded  %s%s healed%s", door.Green, player.BoxHealthy, door.RedHi, player.BoxWounded, door.Yellow, player.BoxHealed, door.Reset), medRecordCol+1, medRecordRow+medBoxRows+2)
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"spacejunk3000/enemy"
+	"spacejunk3000/player"
+	"spacejunk3000/store"
+	"time"
+)
+
+// RunState is the part of a Game that is not saved with the player: the
+// enemies left to fight and the encounter in progress. It is saved after
+// every turn and only deleted when the run ends, so disconnecting cannot be
+// used to escape a fight or re-roll an enemy.
+type RunState struct {
+	Enemies         []enemy.Enemy `json:"enemies"`
+	CurrentEnemy    enemy.Enemy   `json:"current_enemy"`
+	UsedHealthDrone bool          `json:"used_health_drone"`
+	ImplantUses     int           `json:"implant_uses"`
+	CombatLog       []string      `json:"combat_log"`
+	Defeated        int           `json:"defeated"`
+}
+
+// Leaderboard of enemies defeated in a run.
+const defeatedBoard = "defeated"
+
+// SaveRun writes the game's run in progress to the store.
+func SaveRun(g *Game) error {
+	run := RunState{
+		Enemies:         g.Enemies,
+		CurrentEnemy:    g.CurrentEnemy,
+		UsedHealthDrone: g.UsedHealthDrone,
+		ImplantUses:     g.ImplantUses,
+		CombatLog:       g.CombatLog,
+		Defeated:        g.Defeated,
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("error marshaling run state: %v", err)
+	}
+	if err := store.Default().SaveRun(g.Player.ID, data); err != nil {
+		return fmt.Errorf("error writing run state: %v", err)
+	}
+
+	return nil
+}
+
+// LoadRun reads the run state of the player with the given ID. It returns
+// nil and no error if the player has no run in progress.
+func LoadRun(id string) (*RunState, error) {
+	data, err := store.Default().LoadRun(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading run state: %v", err)
+	}
+
+	var run RunState
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("error unmarshaling run state: %v", err)
+	}
+	return &run, nil
+}
+
+// Resume puts the game back where the saved run left off.
+func (g *Game) Resume(run *RunState) {
+	g.Enemies = run.Enemies
+	g.CurrentEnemy = run.CurrentEnemy
+	g.UsedHealthDrone = run.UsedHealthDrone
+	g.ImplantUses = run.ImplantUses
+	g.CombatLog = run.CombatLog
+	g.Defeated = run.Defeated
+	if g.CurrentEnemy.Name != "" {
+		g.logf("You are back in the fight with the %s.", g.CurrentEnemy.Name)
+	}
+}
+
+// EndRun marks the player dead if they died, records the run on the
+// leaderboard and in the event log, and deletes the run state so the next
+// login starts a new run.
+func (g *Game) EndRun() error {
+	event := store.Event{Time: time.Now(), PlayerID: g.Player.ID, Type: "victory"}
+	if g.Player.Health <= 0 {
+		event.Type = "death"
+		if g.QuitGame {
+			event.Detail = "quit"
+		} else if g.CurrentEnemy.Name != "" {
+			event.Detail = fmt.Sprintf("killed by the %s", g.CurrentEnemy.Name)
+		}
+
+		g.Player.Alive = false
+		if err := player.SavePlayer(g.Player); err != nil {
+			return fmt.Errorf("failed to save player: %v", err)
+		}
+	}
+
+	if err := store.Default().AppendEvent(event); err != nil {
+		return fmt.Errorf("failed to log end of run: %v", err)
+	}
+	score := store.Score{PlayerID: g.Player.ID, Name: g.Player.Name, Points: g.Defeated, Time: event.Time}
+	if err := store.Default().AddScore(defeatedBoard, score); err != nil {
+		return fmt.Errorf("failed to record score: %v", err)
+	}
+
+	return DeleteRun(g.Player.ID)
+}
+
+// DeleteRun removes the run state of the player with the given ID.
+func DeleteRun(id string) error {
+	return store.Default().DeleteRun(id)
+}
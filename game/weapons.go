@@ -0,0 +1,109 @@
+package game
+
+import (
+	"fmt"
+	"spacejunk3000/combat"
+	"spacejunk3000/door"
+	"spacejunk3000/dropitem"
+	"spacejunk3000/implant"
+	"spacejunk3000/player"
+	"spacejunk3000/weapon"
+	"strconv"
+)
+
+// ChooseWeapon asks which of the player's weapons to take in hand, by its row
+// in the inventory, and switches to it.
+func (g *Game) ChooseWeapon() error {
+	if len(g.Player.Weapons()) < 2 {
+		g.logf("You have no other weapon to switch to.")
+		return nil
+	}
+
+	g.Term.PrintStringLoc(fmt.Sprintf("%sSwitch to which weapon? %s(1-%d, Esc to cancel) %s", door.Cyan, door.BlackHi, len(g.Player.Weapons()), door.Reset), 1, 24)
+	for {
+		char, err := g.Term.ReadKey()
+		if err != nil {
+			return err
+		}
+		if char == 27 {
+			return nil
+		}
+		if n, err := strconv.Atoi(string(char)); err == nil && n >= 1 && n <= len(g.Player.Weapons()) {
+			return g.SwitchWeapon(n - 1)
+		}
+	}
+}
+
+// SwitchWeapon takes the weapon at index i of the player's weapons in hand,
+// which is also its row in the inventory. Switching takes the player's turn,
+// so the enemy gets a free attack, unless their implant makes it free.
+func (g *Game) SwitchWeapon(i int) error {
+	if i == g.Player.ActiveWeapon {
+		if w := g.Player.CurrentWeapon(); w != nil {
+			g.logf("Your %s is already in hand.", w.Name())
+		}
+		return nil
+	}
+	weapons := g.Player.Weapons()
+	if i < 0 || i >= len(weapons) {
+		g.logf("That is not one of your weapons.")
+		return nil
+	}
+	w := weapons[i]
+	if !w.Use(itemUser{g}) {
+		return nil
+	}
+
+	if effect, ok := g.implantReady(implant.TriggerSwap, weaponMode(w)); ok && effect.Action == implant.ActionFreeSwap {
+		g.useImplant(1)
+		g.logf("Your %s makes the switch in an instant.", g.Player.Implant.Name)
+		return nil
+	}
+	return g.enemyAttack(weaponMode(w))
+}
+
+// Wield takes a weapon the player carries in hand.
+func (u itemUser) Wield(item dropitem.Item) bool {
+	g := u.g
+	for i, w := range g.Player.Weapons() {
+		if dropitem.Item(w) != item {
+			continue
+		}
+		if err := g.Player.SelectWeapon(i); err != nil {
+			return false
+		}
+		g.logf("You switch to your %s.", w.Name())
+		if err := player.SavePlayer(g.Player); err != nil {
+			g.logf("Error saving player data: %v", err)
+		}
+		return true
+	}
+	g.logf("You are not carrying the %s.", item.Name())
+	return false
+}
+
+// weaponMode returns the kind of combat a weapon is used in.
+func weaponMode(w *weapon.Weapon) combat.Mode {
+	if w.WeaponTypeName == "Ranged" {
+		return combat.Ranged
+	}
+	return combat.Close
+}
+
+// rangedWeapon returns the weapon in the player's hand if it is a ranged
+// weapon, or nil if it is not.
+func (g *Game) rangedWeapon() *weapon.Weapon {
+	if w := g.Player.CurrentWeapon(); w != nil && w.WeaponTypeName == "Ranged" {
+		return w
+	}
+	return nil
+}
+
+// blade returns the weapon in the player's hand if it is a Blade, or nil if
+// they would fight unarmed.
+func (g *Game) blade() *weapon.Weapon {
+	if w := g.Player.CurrentWeapon(); w != nil && w.WeaponTypeName == "Blade" {
+		return w
+	}
+	return nil
+}
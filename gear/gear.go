@@ -4,25 +4,56 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"spacejunk3000/dropitem"
+	"strconv"
+	"strings"
 )
 
 // Item represents an item in the game.
 type Gear struct {
-	Name         string `json:"name"`
+	GearName     string `json:"name"`
 	Description  string `json:"description"`
-	Slots        int    `json:"slots"`
+	SlotCount    int    `json:"slots"`
 	GearTypeName string `json:"type"`
 	Heal         int    `json:"heal,omitempty"`
+	Shield       int    `json:"shield,omitempty"` // damage the shield absorbs
 	DamageType   string `json:"damage_type,omitempty"`
 	SingleUse    bool   `json:"single_use"`
+	AmmoType     string `json:"ammo_type,omitempty"` // for Ammo gear, the weapons it loads
+	Rounds       int    `json:"rounds,omitempty"`    // for Ammo gear, the rounds left
+}
+
+// Ammo is the gear type of ammunition for ranged weapons.
+const Ammo = "Ammo"
+
+// What using a piece of gear does, see Gear.Effect.
+const (
+	EffectHeal   = "heal"   // mends Heal wounds
+	EffectShield = "shield" // shields the player from Shield damage
+	EffectDamage = "damage" // hits the enemy with DamageType damage
+)
+
+// Effect returns what using the gear does, or "" if it cannot be used on
+// its own.
+func (g *Gear) Effect() string {
+	switch {
+	case g.Heal > 0:
+		return EffectHeal
+	case g.Shield > 0:
+		return EffectShield
+	case g.DamageType != "":
+		return EffectDamage
+	default:
+		return ""
+	}
 }
 
 // NewItem creates a new item with the given attributes.
 func NewGear(name, description string, slots int, gearTypeName string, heal int, damageType string, singleUse bool) *Gear {
 	return &Gear{
-		Name:         name,
+		GearName:     name,
 		Description:  description,
-		Slots:        slots,
+		SlotCount:    slots,
 		GearTypeName: gearTypeName,
 		Heal:         heal,
 		DamageType:   damageType,
@@ -49,6 +80,88 @@ func (g *Gear) GearType() string {
 	return g.GearTypeName
 }
 
+// Name returns the name of the gear.
+func (g *Gear) Name() string {
+	return g.GearName
+}
+
+// Slots returns the inventory slots the gear takes up.
+func (g *Gear) Slots() int {
+	return g.SlotCount
+}
+
+// Kind returns dropitem.KindGear.
+func (g *Gear) Kind() string {
+	return dropitem.KindGear
+}
+
+// Describe returns a one-line summary of the gear.
+func (g *Gear) Describe() string {
+	desc := fmt.Sprintf("%s (%s), %d slot(s)", g.GearName, strings.ToLower(g.GearTypeName), g.SlotCount)
+	switch {
+	case g.Description != "":
+		desc += ": " + g.Description
+	case g.Effect() == EffectHeal:
+		desc += fmt.Sprintf(": heals %d", g.Heal)
+	case g.Effect() == EffectShield:
+		desc += fmt.Sprintf(": shields %d", g.Shield)
+	case g.Effect() == EffectDamage:
+		desc += fmt.Sprintf(": %s damage", strings.ToLower(g.DamageType))
+	case g.GearTypeName == Ammo:
+		desc += fmt.Sprintf(": %d %s rounds", g.Rounds, strings.ToLower(g.AmmoType))
+	}
+	if g.SingleUse {
+		desc += ", single use"
+	}
+	return desc
+}
+
+// Stats returns the gear's details.
+func (g *Gear) Stats() []dropitem.Stat {
+	stats := []dropitem.Stat{dropitem.TextStat("Type", g.GearTypeName), dropitem.NumStat("Weight", g.SlotCount, false)}
+	if g.Heal > 0 {
+		stats = append(stats, dropitem.NumStat("Heals", g.Heal, true))
+	}
+	if g.Shield > 0 {
+		stats = append(stats, dropitem.NumStat("Shields", g.Shield, true))
+	}
+	if g.DamageType != "" {
+		stats = append(stats, dropitem.TextStat("Damage", g.DamageType))
+	}
+	if g.GearTypeName == Ammo {
+		stats = append(stats, dropitem.TextStat("Rounds", fmt.Sprintf("%d %s", g.Rounds, g.AmmoType)))
+	}
+	if g.SingleUse {
+		stats = append(stats, dropitem.TextStat("Uses", "single use"))
+	}
+	return stats
+}
+
+// Row returns the gear's columns in the inventory list. Only ammunition
+// shows a count, of the rounds left.
+func (g *Gear) Row() dropitem.Row {
+	row := dropitem.Row{Type: g.GearTypeName, Ammo: "-", Fire: "-"}
+	if g.GearTypeName == Ammo {
+		row.Ammo = strconv.Itoa(g.Rounds)
+	}
+	return row
+}
+
+// Use applies the gear's effect, reporting whether it did anything. Gear
+// with no effect, such as ammunition, cannot be used on its own.
+func (g *Gear) Use(u dropitem.User) bool {
+	switch g.Effect() {
+	case EffectHeal:
+		return u.Heal(g, g.Heal)
+	case EffectShield:
+		return u.Shield(g, g.Shield)
+	case EffectDamage:
+		return u.Damage(g, g.DamageType)
+	default:
+		return false
+	}
+}
+
 func (g *Gear) String() string {
-	return fmt.Sprintf("Gear: %s, Type: %s", g.Name, g.GearTypeName)
+	return fmt.Sprintf("Gear: %s, Type: %s", g.GearName, g.GearTypeName)
 }
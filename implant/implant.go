@@ -2,16 +2,61 @@ package implant
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"spacejunk3000/door"
 	"strconv"
+	"strings"
 )
 
 // Implant represents the characteristics of a cybernetic implant.
 type Implant struct {
-	Name string `json:"name"`
-	Desc string `json:"desc"`
+	Name   string `json:"name"`
+	Desc   string `json:"desc"`
+	Effect Effect `json:"effect"`
+}
+
+// Effect is what an implant does in combat, e.g. Targeting is
+//
+//	{"trigger": "on_miss", "mode": "ranged", "uses": 1, "effect": "reroll_shot"}
+type Effect struct {
+	Trigger string `json:"trigger,omitempty"` // when the effect applies, see the Trigger constants
+	Mode    string `json:"mode,omitempty"`    // "ranged", "close", or empty for either
+	Uses    int    `json:"uses,omitempty"`    // times it can be used each encounter, 0 for no limit
+	Action  string `json:"effect,omitempty"`  // what it does, see the Action constants
+	Amount  int    `json:"amount,omitempty"`  // how strong the effect is, where it varies
+}
+
+// When an implant's effect applies.
+const (
+	TriggerAttack = "on_attack" // automatically, whenever the player attacks
+	TriggerMiss   = "on_miss"   // automatically, when a shot misses
+	TriggerManual = "manual"    // when the player chooses to use it
+	TriggerSwap   = "on_swap"   // automatically, when the player switches weapons
+)
+
+// What an implant's effect does.
+const (
+	ActionRerollShot = "reroll_shot" // a missed shot is taken again
+	ActionExtraShot  = "extra_shot"  // a volley fires one more round, for free
+	ActionExtraBlow  = "extra_blow"  // close combat rolls one more die
+	ActionWeakPoint  = "weak_point"  // removes Amount symbols the enemy requires
+	ActionFreeSwap   = "free_swap"   // switching weapons does not take the turn
+)
+
+// Applies reports whether the effect applies on the given trigger in the
+// given combat mode, e.g. "ranged".
+func (e Effect) Applies(trigger, mode string) bool {
+	return e.Action != "" && e.Trigger == trigger && (e.Mode == "" || strings.EqualFold(e.Mode, mode))
+}
+
+// Find returns the implant with the given name, or false if there is none.
+func Find(implants []Implant, name string) (Implant, bool) {
+	for _, i := range implants {
+		if i.Name == name {
+			return i, true
+		}
+	}
+	return Implant{}, false
 }
 
 func NewImplant(name, desc string) *Implant {
@@ -35,21 +80,21 @@ func LoadImplants(filename string) ([]Implant, error) {
 	return implants, nil
 }
 
-func SelectImplant(implants []Implant) Implant {
-	door.ClearScreenAndDisplay("assets/selectImplant.ans")
+// SelectImplant shows the implant selection screen and waits for a choice.
+func SelectImplant(t *door.Terminal, implants []Implant) (Implant, error) {
+	t.ClearScreenAndDisplay("assets/selectImplant.ans")
 
 	for {
-		input, err := door.GetKeyboardInput()
+		input, err := t.GetKeyboardInput()
 		if err != nil {
-			fmt.Println("Error reading keyboard input:", err)
-			continue
+			return Implant{}, err
 		}
 
 		index, err := strconv.Atoi(input)
 		if err == nil && index >= 1 && index <= len(implants) {
-			return implants[index-1]
+			return implants[index-1], nil
 		}
 
-		door.HandleInvalidInput()
+		t.HandleInvalidInput()
 	}
 }
@@ -0,0 +1,148 @@
+package loot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"spacejunk3000/dropitem"
+	"spacejunk3000/gear"
+	"spacejunk3000/weapon"
+)
+
+// DefaultTable is the table used by enemies that do not name one.
+const DefaultTable = "default"
+
+// Rarity tier of an entry when it does not give one.
+const defaultRarity = "common"
+
+// Tables are the loot tables enemies drop items from, with the weight of
+// each rarity tier. They are loaded once, with LoadTables.
+type Tables struct {
+	Rarities map[string]int    `json:"rarities"` // weight of each rarity tier
+	Tables   map[string]*Table `json:"tables"`
+}
+
+// Table is what one kind of enemy can drop.
+type Table struct {
+	Guaranteed []string `json:"guaranteed,omitempty"` // items always dropped
+	Bonus      int      `json:"bonus,omitempty"`      // percent chance of one more roll
+	Entries    []Entry  `json:"entries"`              // items rolled for by weight
+
+	guaranteed []func() dropitem.Item // copies of the guaranteed items, set by LoadTables
+}
+
+// Entry is an item a table can drop. Its chance of being rolled is its
+// weight times the weight of its rarity tier.
+type Entry struct {
+	Item   string `json:"item"`             // name of a weapon or piece of gear
+	Weight int    `json:"weight,omitempty"` // 1 if not given
+	Rarity string `json:"rarity,omitempty"` // "common" if not given
+
+	newItem func() dropitem.Item // a fresh copy of the item, set by LoadTables
+}
+
+// LoadTables loads loot tables from a specified JSON file, and finds each
+// item they name in the weapon and gear catalogues.
+func LoadTables(filename string, weapons []weapon.Weapon, gears []gear.Gear) (*Tables, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var t Tables
+	if err := json.Unmarshal(bytes, &t); err != nil {
+		return nil, err
+	}
+	if t.Tables[DefaultTable] == nil {
+		return nil, fmt.Errorf("no %q loot table", DefaultTable)
+	}
+
+	for name, table := range t.Tables {
+		for _, item := range table.Guaranteed {
+			newItem := findItem(item, weapons, gears)
+			if newItem == nil {
+				return nil, fmt.Errorf("loot table %q: unknown item %q", name, item)
+			}
+			table.guaranteed = append(table.guaranteed, newItem)
+		}
+		for i := range table.Entries {
+			e := &table.Entries[i]
+			if e.Weight == 0 {
+				e.Weight = 1
+			}
+			if e.Rarity == "" {
+				e.Rarity = defaultRarity
+			}
+			if _, ok := t.Rarities[e.Rarity]; !ok {
+				return nil, fmt.Errorf("loot table %q: unknown rarity %q", name, e.Rarity)
+			}
+			if e.newItem = findItem(e.Item, weapons, gears); e.newItem == nil {
+				return nil, fmt.Errorf("loot table %q: unknown item %q", name, e.Item)
+			}
+		}
+	}
+	return &t, nil
+}
+
+// findItem returns a function making copies of the named weapon or piece of
+// gear, so the catalogue is not changed when a dropped item is used. It
+// returns nil if there is no such item.
+func findItem(name string, weapons []weapon.Weapon, gears []gear.Gear) func() dropitem.Item {
+	for _, w := range weapons {
+		if w.WeaponName == name {
+			return func() dropitem.Item { c := w; return &c }
+		}
+	}
+	for _, g := range gears {
+		if g.GearName == name {
+			return func() dropitem.Item { c := g; return &c }
+		}
+	}
+	return nil
+}
+
+// Drop returns the items an enemy drops from the named table: its
+// guaranteed items, then rolls weighted rolls, then one more roll if its
+// bonus chance comes up. A table that does not exist falls back to the
+// default one.
+func (t *Tables) Drop(name string, rolls int, rng *rand.Rand) []dropitem.Item {
+	table := t.Tables[name]
+	if table == nil {
+		table = t.Tables[DefaultTable]
+	}
+
+	var items []dropitem.Item
+	for _, newItem := range table.guaranteed {
+		items = append(items, newItem())
+	}
+	if table.Bonus > 0 && rng.Intn(100) < table.Bonus {
+		rolls++
+	}
+	for i := 0; i < rolls; i++ {
+		if e := t.roll(table, rng); e != nil {
+			items = append(items, e.newItem())
+		}
+	}
+	return items
+}
+
+// roll picks one of a table's entries by weight, or nil if it has none.
+func (t *Tables) roll(table *Table, rng *rand.Rand) *Entry {
+	total := 0
+	for _, e := range table.Entries {
+		total += e.Weight * t.Rarities[e.Rarity]
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	n := rng.Intn(total)
+	for i := range table.Entries {
+		e := &table.Entries[i]
+		n -= e.Weight * t.Rarities[e.Rarity]
+		if n < 0 {
+			return e
+		}
+	}
+	return nil
+}
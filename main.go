@@ -1,80 +1,177 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"spacejunk3000/door"
+	"spacejunk3000/dropfile"
 	"spacejunk3000/enemy"
 	"spacejunk3000/game"
+	"spacejunk3000/gear"
 	"spacejunk3000/implant"
+	"spacejunk3000/loot"
 	"spacejunk3000/player"
+	"spacejunk3000/store"
 	"spacejunk3000/weapon"
 	"syscall"
+	"time"
 )
 
 func main() {
-	// Trap SIGINT (Ctrl+C) and SIGTERM signals to gracefully exit the program
-	go func() {
-		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-		<-sig
-		fmt.Println("\nExiting the game...")
-		os.Exit(0)
-	}()
-
-	door.ClearScreen()
-
 	// Define flags
-	dropfilePath := flag.String("door32", "", "path to the Door32.sys drop file")
+	dropfilePath := flag.String("dropfile", "", "path to the drop file, or the directory the BBS wrote it to")
+	door32Path := flag.String("door32", "", "same as -dropfile, kept for existing BBS setups")
+	idleTimeout := flag.Duration("idle", 5*time.Minute, "how long to wait for a key press before ending the session, 0 to disable")
+	storeSpec := flag.String("store", "files:data", "where players, runs and scores are saved: files:DIR or db:FILE")
+	copyTo := flag.String("copystore", "", "copy everything in -store to this store, e.g. db:data/spacejunk.db, and exit")
+	seed := flag.Int64("seed", 0, "seed for the dice, loot and starting weapon rolls, to make a session repeatable; 0 picks one at random")
 	flag.Parse()
 
+	s, err := store.Open(*storeSpec)
+	if err != nil {
+		log.Fatalf("Error opening store: %v", err)
+	}
+	defer s.Close()
+	store.SetDefault(s)
+
+	// Move saved data to another store instead of playing
+	if *copyTo != "" {
+		if err := copyStore(s, *copyTo); err != nil {
+			s.Close()
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *dropfilePath == "" {
+		*dropfilePath = *door32Path
+	}
+
 	// Check if dropfile flag is provided
 	if *dropfilePath == "" {
-		log.Fatal("Dropfile path is required. Please provide the path using the -door32 flag.")
+		log.Fatal("Dropfile path is required. Please provide the path using the -dropfile flag.")
+	}
+
+	if err := run(*dropfilePath, *idleTimeout, *seed); err != nil {
+		s.Close()
+		log.Fatal(err)
+	}
+}
+
+// copyStore copies everything in src to the store described by spec.
+func copyStore(src store.Store, spec string) error {
+	dst, err := store.Open(spec)
+	if err != nil {
+		return fmt.Errorf("error opening store: %v", err)
+	}
+	defer dst.Close()
+
+	if err := store.Copy(dst, src); err != nil {
+		return fmt.Errorf("error copying store: %v", err)
 	}
+	fmt.Printf("Copied saved data to %s\n", spec)
+	return nil
+}
 
+// run plays one session for the user described by the drop file. Every way
+// out of the session, including a hangup, returns through here so the
+// terminal is restored and the player's progress is saved.
+func run(dropfilePath string, idleTimeout time.Duration, seed int64) error {
 	// Get BBS dropfile information about the user
-	dropAlias, dropTimeLeft, dropEmulation, nodeNum, err := door.DropFileData(*dropfilePath)
+	drop, err := dropfile.Load(dropfilePath)
 	if err != nil {
-		log.Fatalf("Error processing drop file: %v", err)
+		return fmt.Errorf("error processing drop file: %v", err)
 	}
 
-	// Use dropAlias as the playerName
-	playerName := dropAlias
+	// Use the drop file alias as the playerName
+	playerName := drop.Alias
 
-	// Load or create player
-	p, err := player.LoadPlayer(playerName)
+	// Open the user's connection for all game input and output
+	term, err := door.OpenTerminal(drop.CommType, drop.CommHandle)
+	if err != nil {
+		return fmt.Errorf("error opening terminal: %v", err)
+	}
+	defer func() {
+		term.Print(door.Reset)
+		term.CursorShow()
+		term.Close()
+	}()
+
+	// A hangup or a request to stop ends the session the same way as the
+	// user's connection dropping: reads fail and the game unwinds and saves.
+	// Trapping SIGPIPE turns a write to a closed connection into an error.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGPIPE)
+	defer signal.Stop(sig)
+	go func() {
+		for range sig {
+			term.Hangup()
+		}
+	}()
+
+	// End the session when the user's BBS time runs out or they walk away
+	if drop.TimeLeft > 0 {
+		term.SetTimeLimit(time.Duration(drop.TimeLeft) * time.Minute)
+	}
+	term.SetIdleTimeout(idleTimeout)
+
+	term.ClearScreen()
+
+	// Hold the player's save for the whole session so another node cannot
+	// load it at the same time
+	lock, err := player.LockPlayer(playerName, drop.Node)
+	var inUse *player.InUseError
+	if errors.As(err, &inUse) {
+		return showInUse(term, inUse)
+	}
 	if err != nil {
+		return fmt.Errorf("failed to lock player: %v", err)
+	}
+	defer lock.Unlock()
 
-		door.ClearScreen()
-		door.CursorHide()
-		door.DisplayAnsiFile("assets/start.ans", false)
+	// Load or create player. Any error but a missing save ends the session,
+	// so a save that cannot be read is never overwritten by a new player.
+	p, err := player.LoadPlayer(playerName)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to load player: %v", err)
+	}
+	if p == nil {
 
-		err := door.WaitForAnyKey()
+		term.ClearScreen()
+		term.CursorHide()
+		term.DisplayAnsiFile("assets/start.ans", false)
+
+		err := term.WaitForAnyKey()
 		if err != nil {
-			fmt.Println("Error:", err)
-			return
+			return endSession(term, nil, err)
 		}
 
 		// Select character type
-		charType := game.SelectCharacterType()
+		charType, err := game.SelectCharacterType(term)
+		if err != nil {
+			return endSession(term, nil, err)
+		}
 
 		// Load implants from JSON file
 		implants, err := implant.LoadImplants("data/implants.json")
 		if err != nil {
-			log.Fatalf("Failed to load implants: %v", err)
+			return fmt.Errorf("failed to load implants: %v", err)
 		}
 
 		// Select implant
-		selectedImplant := implant.SelectImplant(implants)
+		selectedImplant, err := implant.SelectImplant(term, implants)
+		if err != nil {
+			return endSession(term, nil, err)
+		}
 
 		// Create a new player with default values, dropfile information, character type, and selected implant
-		p, err = player.NewPlayer(playerName, charType, dropTimeLeft, nodeNum, dropEmulation)
+		p, err = player.NewPlayer(playerName, charType, drop.TimeLeft, drop.Node, drop.Emulation)
 		if err != nil {
-			log.Fatalf("Failed to create new player: %v", err)
+			return fmt.Errorf("failed to create new player: %v", err)
 		}
 
 		// Set the selected implant for the player
@@ -82,56 +179,160 @@ func main() {
 
 		// Save the new player
 		if err := player.SavePlayer(p); err != nil {
-			log.Fatalf("Failed to save new player: %v", err)
+			return fmt.Errorf("failed to save new player: %v", err)
 		}
 	}
 
 	// Load enemies from JSON file
 	enemies, err := enemy.LoadEnemies("data/enemies.json")
 	if err != nil {
-		log.Fatalf("Failed to load enemies: %v", err)
+		return fmt.Errorf("failed to load enemies: %v", err)
 	}
 
 	// Load weapons from JSON file
 	weapons, err := weapon.LoadWeapons("data/weapons.json")
 	if err != nil {
-		log.Fatalf("Failed to load weapons: %v", err)
+		return fmt.Errorf("failed to load weapons: %v", err)
+	}
+
+	// Load gear from JSON file
+	gears, err := gear.LoadGear("data/gear.json")
+	if err != nil {
+		return fmt.Errorf("failed to load gear: %v", err)
 	}
 
 	// Load implants from JSON file
 	implants, err := implant.LoadImplants("data/implants.json")
 	if err != nil {
-		log.Fatalf("Failed to load implants: %v", err)
+		return fmt.Errorf("failed to load implants: %v", err)
+	}
+
+	// Load loot tables from JSON file
+	tables, err := loot.LoadTables("data/loot.json", weapons, gears)
+	if err != nil {
+		return fmt.Errorf("failed to load loot tables: %v", err)
+	}
+
+	// Drop falls back to the default table, so a misspelt table name would
+	// otherwise go unnoticed. Enemies that name no table use the default.
+	for _, e := range enemies {
+		if e.LootTable != "" && tables.Tables[e.LootTable] == nil {
+			return fmt.Errorf("enemy %q has unknown loot table %q", e.Name, e.LootTable)
+		}
 	}
 
 	// Initialize and start the game with all required arguments
-	g, err := game.NewGame(playerName, p.Type, weapons, implants, enemies)
+	g, err := game.NewGame(term, playerName, p.Type, weapons, gears, implants, enemies, tables, seed)
+	if isSessionEnd(err) {
+		return endSession(term, nil, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize game: %v", err)
+	}
+
+	// Session details from the drop file are not saved with the player
+	g.Player.TimeLeft = drop.TimeLeft
+	g.Player.NodeNum = drop.Node
+	g.Player.Emulation = drop.Emulation
+
+	// Pick up the run where the player's last session left off
+	run, err := game.LoadRun(g.Player.ID)
 	if err != nil {
-		log.Fatalf("Failed to initialize game: %v", err)
+		return fmt.Errorf("failed to load run state: %v", err)
+	}
+	if run != nil {
+		g.Resume(run)
 	}
 
 	// Start the game loop
 	for {
-		game.StartNewEncounter(g)
+		if err := g.StartNewEncounter(); err != nil {
+			return endSession(term, g, err)
+		}
 		// Check if player is dead
 		if g.Player.Health <= 0 {
-			fmt.Println("You have died!")
+			term.Println("You have died!")
 			break
 		}
 		// Check if player has defeated all enemies
 		if len(g.Enemies) == 0 {
-			fmt.Println("You have defeated all enemies!")
+			term.Println("You have defeated all enemies!")
 			break
 		}
 		// Check if player has reached the end of the game
 		if g.Player.NodeNum == 10 {
-			fmt.Println("You have reached the end of the game!")
+			term.Println("You have reached the end of the game!")
 			break
 		}
+		// Quitting forfeits the run
 		if g.QuitGame {
+			g.Player.Kill("quit")
 			break
 		}
 	}
 
-	fmt.Println("Goodbye!")
+	// The run is over, so the next login starts a new one
+	if g.Player.Health <= 0 || len(g.Enemies) == 0 {
+		if err := g.EndRun(); err != nil {
+			return fmt.Errorf("failed to end run: %v", err)
+		}
+	}
+
+	term.Println("Goodbye!")
+	return nil
+}
+
+// showInUse tells the user their player is already in a game on another node.
+func showInUse(term *door.Terminal, inUse *player.InUseError) error {
+	msg := "You are already playing on another node."
+	if inUse.Node > 0 {
+		msg = fmt.Sprintf("You are already playing on node %d.", inUse.Node)
+	}
+
+	term.CursorHide()
+	term.MoveCursor(1, 10)
+	term.Println(door.CenterAlignText(msg, 80, door.YellowHi, door.BgBlack), door.Reset)
+	term.Println(door.CenterAlignText("Finish that game first, then come back.", 80, door.Cyan, door.BgBlack), door.Reset)
+	term.Println()
+	term.Print(door.CenterAlignText("Press any key to continue...", 80, door.BlackHi, door.BgBlack), door.Reset)
+
+	if err := term.WaitForAnyKey(); err != nil && !isSessionEnd(err) {
+		return err
+	}
+	return nil
+}
+
+// isSessionEnd reports whether err means the user's session is over rather
+// than something having gone wrong with the game.
+func isSessionEnd(err error) bool {
+	return errors.Is(err, door.ErrTimeUp) || errors.Is(err, door.ErrIdle) || errors.Is(err, door.ErrHangup)
+}
+
+// endSession saves the player and the encounter in progress, and tells the
+// user why their session ended, e.g. because their BBS time ran out or they
+// stopped pressing keys. Nothing is printed once the user has hung up.
+func endSession(term *door.Terminal, g *game.Game, err error) error {
+	if !isSessionEnd(err) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, door.ErrTimeUp):
+		term.Println("\r\n\r\nYour time on the BBS is up.")
+	case errors.Is(err, door.ErrIdle):
+		term.Println("\r\n\r\nYou have been idle too long.")
+	}
+
+	if g == nil {
+		return nil
+	}
+	if err := player.SavePlayer(g.Player); err != nil {
+		return fmt.Errorf("failed to save player: %v", err)
+	}
+	if err := game.SaveRun(g); err != nil {
+		return fmt.Errorf("failed to save run state: %v", err)
+	}
+
+	term.Println("Your progress has been saved. Goodbye!")
+	return nil
 }
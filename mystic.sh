@@ -3,4 +3,4 @@
 # Start SpaceJunk3000
 echo "Starting SpaceJunk3000"
 cd ~/git/spacejunk3000
-bin/spacejunk3000 -door32 ~/mystic/temp1/
\ No newline at end of file
+bin/spacejunk3000 -dropfile ~/mystic/temp1/
\ No newline at end of file
@@ -0,0 +1,163 @@
+package player
+
+// A player's health is their medical record: one box per point of health,
+// each healthy, wounded or healed. Health is always the number of boxes that
+// are not wounded, so it is only ever changed through the methods here.
+
+// Boxes in a new player's medical record.
+const defaultMaxHealth = 12
+
+// Marks in a medical record box. A healed box has been wounded before and can
+// be wounded again.
+const (
+	BoxHealthy = "-"
+	BoxWounded = "\\"
+	BoxHealed  = "/"
+)
+
+// Where the damage came from when it is not known.
+const unknownSource = "unknown"
+
+// HealthChange is what a change to the player's health did.
+type HealthChange struct {
+	Shielded int  // damage the shield absorbed
+	Wounds   int  // boxes wounded
+	Healed   int  // boxes mended
+	Died     bool // the change took the player's health to zero
+}
+
+// newHealthRecord returns a medical record with every box healthy.
+func newHealthRecord(boxes int) []string {
+	record := make([]string, boxes)
+	for i := range record {
+		record[i] = BoxHealthy
+	}
+	return record
+}
+
+// resetHealth restores the player to full health with a clean record.
+func (p *Player) resetHealth() {
+	p.HealthRecord = newHealthRecord(defaultMaxHealth)
+	p.WoundSources = make([][]string, defaultMaxHealth)
+	p.Shield = 0
+	p.syncHealth()
+}
+
+// syncHealth sets Health from the health record, and makes sure every box
+// has a list of wound sources.
+func (p *Player) syncHealth() {
+	for len(p.WoundSources) < len(p.HealthRecord) {
+		p.WoundSources = append(p.WoundSources, nil)
+	}
+	p.WoundSources = p.WoundSources[:len(p.HealthRecord)]
+
+	p.Health = 0
+	for _, box := range p.HealthRecord {
+		if box != BoxWounded {
+			p.Health++
+		}
+	}
+}
+
+// MaxHealth returns the player's health when they have no wounds.
+func (p *Player) MaxHealth() int {
+	return len(p.HealthRecord)
+}
+
+// SetMaxHealth grows or shrinks the medical record to the given number of
+// boxes. New boxes are healthy, unless the player is dead; boxes are removed
+// from the end.
+func (p *Player) SetMaxHealth(boxes int) {
+	boxes = max(boxes, 1)
+	box := BoxHealthy
+	if p.Health <= 0 && len(p.HealthRecord) > 0 {
+		box = BoxWounded
+	}
+	for len(p.HealthRecord) < boxes {
+		p.HealthRecord = append(p.HealthRecord, box)
+	}
+	p.HealthRecord = p.HealthRecord[:boxes]
+	p.syncHealth()
+}
+
+// AddShield gives the player a temporary shield that absorbs the given amount
+// of damage before any boxes are wounded. The game clears it at the end of
+// each encounter.
+func (p *Player) AddShield(amount int) {
+	p.Shield += max(amount, 0)
+}
+
+// ClearShield removes whatever is left of the player's shield.
+func (p *Player) ClearShield() {
+	p.Shield = 0
+}
+
+// AdjustHealth heals the player by a positive amount, or wounds them by a
+// negative one from an unknown source.
+func (p *Player) AdjustHealth(amount int) HealthChange {
+	if amount < 0 {
+		return p.Wound(-amount, unknownSource)
+	}
+	return p.Heal(amount)
+}
+
+// Wound deals damage to the player from the given source, e.g. an enemy's
+// name. The shield takes what it can, then each point wounds a box of the
+// medical record and notes the source against it. Damage beyond the last box
+// is lost: health never goes below zero.
+func (p *Player) Wound(damage int, source string) HealthChange {
+	var change HealthChange
+	if damage <= 0 || p.Health <= 0 {
+		return change
+	}
+	if source == "" {
+		source = unknownSource
+	}
+
+	change.Shielded = min(damage, p.Shield)
+	p.Shield -= change.Shielded
+	damage -= change.Shielded
+
+	p.syncHealth()
+	for i := range p.HealthRecord {
+		if damage == 0 {
+			break
+		}
+		if p.HealthRecord[i] != BoxWounded {
+			p.HealthRecord[i] = BoxWounded
+			p.WoundSources[i] = append(p.WoundSources[i], source)
+			change.Wounds++
+			damage--
+		}
+	}
+	p.syncHealth()
+
+	change.Died = p.Health == 0
+	return change
+}
+
+// Heal mends up to amount wounded boxes, from the end of the record. Healing
+// is capped by the player's wounds, so it never takes them above MaxHealth,
+// and it cannot bring back a player whose health is already zero.
+func (p *Player) Heal(amount int) HealthChange {
+	var change HealthChange
+	if p.Health <= 0 {
+		return change
+	}
+
+	for i := len(p.HealthRecord) - 1; i >= 0 && change.Healed < amount; i-- {
+		if p.HealthRecord[i] == BoxWounded {
+			p.HealthRecord[i] = BoxHealed
+			change.Healed++
+		}
+	}
+	p.syncHealth()
+	return change
+}
+
+// Kill wounds every remaining box from the given source, e.g. when the
+// player gives up.
+func (p *Player) Kill(source string) HealthChange {
+	p.Shield = 0
+	return p.Wound(p.MaxHealth(), source)
+}
@@ -0,0 +1,101 @@
+package player
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// healthStep is a change to a player's health, described for failures.
+type healthStep struct {
+	name  string
+	apply func(p *Player)
+}
+
+// randomHealthStep returns one of the health methods with random arguments,
+// some of them out of range.
+func randomHealthStep(rng *rand.Rand) healthStep {
+	n := rng.Intn(20) - 4
+	switch rng.Intn(6) {
+	case 0:
+		return healthStep{fmt.Sprintf("Wound(%d)", n), func(p *Player) { p.Wound(n, "test") }}
+	case 1:
+		return healthStep{fmt.Sprintf("Heal(%d)", n), func(p *Player) { p.Heal(n) }}
+	case 2:
+		return healthStep{fmt.Sprintf("AdjustHealth(%d)", n), func(p *Player) { p.AdjustHealth(n) }}
+	case 3:
+		return healthStep{fmt.Sprintf("AddShield(%d)", n), func(p *Player) { p.AddShield(n) }}
+	case 4:
+		return healthStep{fmt.Sprintf("SetMaxHealth(%d)", n), func(p *Player) { p.SetMaxHealth(n) }}
+	default:
+		return healthStep{"Kill", func(p *Player) { p.Kill("test") }}
+	}
+}
+
+func TestHealthInvariants(t *testing.T) {
+	for seed := int64(1); seed <= 500; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		p := &Player{}
+		p.resetHealth()
+
+		var steps []string
+		for i := 0; i < 60; i++ {
+			step := randomHealthStep(rng)
+			steps = append(steps, step.name)
+			wasDead := p.Health <= 0
+
+			step.apply(p)
+
+			fail := func(format string, args ...any) {
+				t.Fatalf("seed %d, after %v: %s", seed, steps, fmt.Sprintf(format, args...))
+			}
+			healthy := 0
+			for _, box := range p.HealthRecord {
+				if box != BoxWounded {
+					healthy++
+				}
+			}
+			if p.Health != healthy {
+				fail("Health = %d, but %d boxes are not wounded", p.Health, healthy)
+			}
+			if len(p.WoundSources) != len(p.HealthRecord) {
+				fail("%d wound sources for %d boxes", len(p.WoundSources), len(p.HealthRecord))
+			}
+			if p.Health < 0 || p.Health > p.MaxHealth() {
+				fail("Health = %d, want 0 to %d", p.Health, p.MaxHealth())
+			}
+			if wasDead && p.Health != 0 {
+				fail("dead player healed to %d", p.Health)
+			}
+			if p.Shield < 0 {
+				fail("Shield = %d", p.Shield)
+			}
+		}
+	}
+}
+
+func TestWoundShield(t *testing.T) {
+	p := &Player{}
+	p.resetHealth()
+	p.AddShield(3)
+
+	change := p.Wound(5, "Security Drone")
+	if change.Shielded != 3 || change.Wounds != 2 {
+		t.Errorf("Wound = %+v, want 3 shielded and 2 wounds", change)
+	}
+	if p.Shield != 0 || p.Health != p.MaxHealth()-2 {
+		t.Errorf("Shield = %d and Health = %d, want 0 and %d", p.Shield, p.Health, p.MaxHealth()-2)
+	}
+	if got := p.WoundSources[0]; len(got) != 1 || got[0] != "Security Drone" {
+		t.Errorf("WoundSources[0] = %q", got)
+	}
+
+	p.AddShield(4)
+	p.ClearShield()
+	if change := p.Wound(1, ""); change.Shielded != 0 || change.Wounds != 1 {
+		t.Errorf("Wound after ClearShield = %+v", change)
+	}
+	if got := p.WoundSources[2]; len(got) != 1 || got[0] != unknownSource {
+		t.Errorf("WoundSources[2] = %q, want the unknown source", got)
+	}
+}
@@ -0,0 +1,122 @@
+package player
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"spacejunk3000/store"
+	"strings"
+)
+
+// Longest slug kept at the front of a player ID.
+const maxSlugLen = 24
+
+// PlayerID returns the ID used to name the files of the player with the given
+// alias. It is a readable slug of the alias followed by a hash, e.g.
+// "space-ace-1a2b3c4d", so it is always a safe file name and two aliases that
+// slug the same still get different IDs. Aliases differing only in case or
+// surrounding spaces are the same player, as they are on the BBS.
+func PlayerID(alias string) string {
+	key := aliasKey(alias)
+
+	var slug strings.Builder
+	dash := false
+	for _, r := range key {
+		if slug.Len() >= maxSlugLen {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteByte('-')
+			dash = true
+		}
+	}
+	s := strings.TrimRight(slug.String(), "-")
+	if s == "" {
+		s = "player"
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return s + "-" + hex.EncodeToString(sum[:4])
+}
+
+// aliasKey returns the form of an alias that player lookups compare.
+func aliasKey(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+// LookupID returns the ID of the player with the given alias, from the player
+// index if they are in it.
+func LookupID(alias string) (string, error) {
+	index, err := loadIndex()
+	if err != nil {
+		return "", err
+	}
+	if id, ok := index[aliasKey(alias)]; ok {
+		return id, nil
+	}
+	return PlayerID(alias), nil
+}
+
+// loadIndex reads the alias to ID index. A missing index is empty.
+func loadIndex() (map[string]string, error) {
+	index := make(map[string]string)
+	data, err := store.Default().LoadIndex()
+	if errors.Is(err, store.ErrNotFound) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading player index: %v", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error unmarshaling player index: %v", err)
+	}
+	return index, nil
+}
+
+// registerID adds the player to the alias to ID index if they are missing.
+func registerID(alias, id string) error {
+	index, err := loadIndex()
+	if err != nil {
+		return err
+	}
+	if index[aliasKey(alias)] == id {
+		return nil
+	}
+	index[aliasKey(alias)] = id
+
+	data, err := json.MarshalIndent(index, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling player index: %v", err)
+	}
+	if err := store.Default().SaveIndex(data); err != nil {
+		return fmt.Errorf("error writing player index: %v", err)
+	}
+	return nil
+}
+
+// migrateLegacy moves a save stored under the player's alias, from before
+// saves were named by ID, to their ID. It returns the save's contents, or
+// store.ErrNotFound if the player has no old save.
+func migrateLegacy(alias, id string) ([]byte, error) {
+	// Aliases that were never safe file names cannot have old saves
+	if alias == "" || alias == "." || alias == ".." || strings.ContainsAny(alias, `/\:`) {
+		return nil, store.ErrNotFound
+	}
+	data, err := store.Default().LoadPlayer(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Default().SavePlayer(id, data); err != nil {
+		return nil, fmt.Errorf("error migrating player data: %v", err)
+	}
+	if err := store.Default().DeletePlayer(alias); err != nil {
+		return nil, fmt.Errorf("error removing old player data: %v", err)
+	}
+	return data, nil
+}
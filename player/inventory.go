@@ -0,0 +1,163 @@
+package player
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"spacejunk3000/dropitem"
+	"spacejunk3000/gear"
+	"spacejunk3000/weapon"
+)
+
+// Slots a new player can fill with weapons and gear.
+const defaultCapacity = 4
+
+// ErrNoRoom is returned when an item does not fit in an inventory.
+var ErrNoRoom = errors.New("cannot carry that much")
+
+// Inventory is everything a player carries. Weapons and gear share its
+// capacity: each item takes up its own number of slots.
+type Inventory struct {
+	Capacity int
+	items    []dropitem.Item
+}
+
+// NewInventory returns an empty inventory with the given number of slots.
+func NewInventory(capacity int) *Inventory {
+	return &Inventory{Capacity: capacity}
+}
+
+// Items returns every item in the inventory: its weapons, then its gear,
+// each in the order they were added.
+func (inv *Inventory) Items() []dropitem.Item {
+	return inv.items
+}
+
+// Used returns the slots the inventory's items take up.
+func (inv *Inventory) Used() int {
+	used := 0
+	for _, item := range inv.items {
+		used += item.Slots()
+	}
+	return used
+}
+
+// Fits reports whether an item would fit in the inventory once the item it
+// replaces, if not nil, has been taken out.
+func (inv *Inventory) Fits(item, replacing dropitem.Item) bool {
+	free := inv.Capacity - inv.Used()
+	if replacing != nil && inv.Find(func(i dropitem.Item) bool { return i == replacing }) != nil {
+		free += replacing.Slots()
+	}
+	return item.Slots() <= free
+}
+
+// Add puts an item in the inventory, or returns ErrNoRoom if it does not fit.
+func (inv *Inventory) Add(item dropitem.Item) error {
+	if !inv.Fits(item, nil) {
+		return ErrNoRoom
+	}
+
+	// Weapons are kept ahead of gear, so a weapon's row in the inventory
+	// is its index in Weapons
+	if item.Kind() == dropitem.KindWeapon {
+		i := len(inv.Weapons())
+		inv.items = append(inv.items[:i], append([]dropitem.Item{item}, inv.items[i:]...)...)
+		return nil
+	}
+	inv.items = append(inv.items, item)
+	return nil
+}
+
+// Remove takes an item out of the inventory, reporting whether it was there.
+func (inv *Inventory) Remove(item dropitem.Item) bool {
+	for i, it := range inv.items {
+		if it == item {
+			inv.items = append(inv.items[:i], inv.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the first item that matches, or nil if none do.
+func (inv *Inventory) Find(match func(dropitem.Item) bool) dropitem.Item {
+	for _, item := range inv.items {
+		if match(item) {
+			return item
+		}
+	}
+	return nil
+}
+
+// Weapons returns the weapons in the inventory.
+func (inv *Inventory) Weapons() []*weapon.Weapon {
+	var weapons []*weapon.Weapon
+	for _, item := range inv.items {
+		if w, ok := item.(*weapon.Weapon); ok {
+			weapons = append(weapons, w)
+		}
+	}
+	return weapons
+}
+
+// Gear returns the gear in the inventory.
+func (inv *Inventory) Gear() []*gear.Gear {
+	var gears []*gear.Gear
+	for _, item := range inv.items {
+		if g, ok := item.(*gear.Gear); ok {
+			gears = append(gears, g)
+		}
+	}
+	return gears
+}
+
+// inventoryJSON is how an inventory is saved. Each item is saved with its
+// Kind so it can be read back as the right type.
+type inventoryJSON struct {
+	Capacity int             `json:"capacity"`
+	Items    []inventoryItem `json:"items"`
+}
+
+type inventoryItem struct {
+	Kind string          `json:"kind"`
+	Item json.RawMessage `json:"item"`
+}
+
+func (inv *Inventory) MarshalJSON() ([]byte, error) {
+	saved := inventoryJSON{Capacity: inv.Capacity, Items: []inventoryItem{}}
+	for _, item := range inv.items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		saved.Items = append(saved.Items, inventoryItem{Kind: item.Kind(), Item: data})
+	}
+	return json.Marshal(saved)
+}
+
+func (inv *Inventory) UnmarshalJSON(data []byte) error {
+	var saved inventoryJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	inv.Capacity = saved.Capacity
+	inv.items = nil
+	for _, it := range saved.Items {
+		var item dropitem.Item
+		switch it.Kind {
+		case dropitem.KindWeapon:
+			item = &weapon.Weapon{}
+		case dropitem.KindGear:
+			item = &gear.Gear{}
+		default:
+			return fmt.Errorf("unknown item kind %q", it.Kind)
+		}
+		if err := json.Unmarshal(it.Item, item); err != nil {
+			return err
+		}
+		inv.items = append(inv.items, item)
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+package player
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"spacejunk3000/dropitem"
+	"spacejunk3000/gear"
+	"spacejunk3000/store"
+	"spacejunk3000/weapon"
+	"testing"
+)
+
+func newWeapon(name string, slots int) *weapon.Weapon {
+	return &weapon.Weapon{WeaponName: name, SlotCount: slots, WeaponTypeName: "Gun", AmmoType: "Slugs", AmmoCapacity: 6, Ammo: 6}
+}
+
+func newGear(name string, slots int) *gear.Gear {
+	return &gear.Gear{GearName: name, SlotCount: slots, GearTypeName: "Medical", Heal: 2, SingleUse: true}
+}
+
+// names returns the names of items, in order.
+func names(items []dropitem.Item) []string {
+	var n []string
+	for _, item := range items {
+		n = append(n, item.Name())
+	}
+	return n
+}
+
+func TestInventoryCapacity(t *testing.T) {
+	inv := NewInventory(4)
+	if err := inv.Add(newWeapon("Hand Cannon", 2)); err != nil {
+		t.Fatalf("Add weapon: %v", err)
+	}
+	if err := inv.Add(newGear("Health Potion", 1)); err != nil {
+		t.Fatalf("Add gear: %v", err)
+	}
+	if got := inv.Used(); got != 3 {
+		t.Errorf("Used = %d, want 3", got)
+	}
+
+	// Weapons and gear draw on the same slots
+	if err := inv.Add(newGear("Med Kit", 2)); !errors.Is(err, ErrNoRoom) {
+		t.Errorf("Add of gear past capacity = %v, want ErrNoRoom", err)
+	}
+	if err := inv.Add(newWeapon("Alien Blade", 2)); !errors.Is(err, ErrNoRoom) {
+		t.Errorf("Add of a weapon past capacity = %v, want ErrNoRoom", err)
+	}
+	if got := names(inv.Items()); !reflect.DeepEqual(got, []string{"Hand Cannon", "Health Potion"}) {
+		t.Errorf("Items after ErrNoRoom = %q", got)
+	}
+
+	if err := inv.Add(newWeapon("Ray Gun", 1)); err != nil {
+		t.Fatalf("Add to fill the last slot: %v", err)
+	}
+	if got := inv.Used(); got != inv.Capacity {
+		t.Errorf("Used = %d, want %d", got, inv.Capacity)
+	}
+	if err := inv.Add(newGear("Slug Box", 0)); err != nil {
+		t.Errorf("Add of an item taking no slots: %v", err)
+	}
+}
+
+func TestInventoryFits(t *testing.T) {
+	inv := NewInventory(4)
+	cannon := newWeapon("Hand Cannon", 2)
+	potion := newGear("Health Potion", 1)
+	inv.Add(cannon)
+	inv.Add(potion)
+
+	blade := newWeapon("Alien Blade", 2)
+	tests := []struct {
+		name      string
+		item      dropitem.Item
+		replacing dropitem.Item
+		want      bool
+	}{
+		{"free slot", newWeapon("Ray Gun", 1), nil, true},
+		{"too big", blade, nil, false},
+		{"replacing a weapon", blade, cannon, true},
+		{"replacing gear", blade, potion, true},
+		{"replacing too little", newWeapon("Big Gun", 4), potion, false},
+		{"replacing an item not carried", blade, newWeapon("Hand Cannon", 2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inv.Fits(tt.item, tt.replacing); got != tt.want {
+				t.Errorf("Fits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := inv.Used(); got != 3 {
+		t.Errorf("Fits changed the inventory: Used = %d, want 3", got)
+	}
+}
+
+func TestInventoryOrder(t *testing.T) {
+	inv := NewInventory(10)
+	inv.Add(newGear("Health Potion", 1))
+	inv.Add(newWeapon("Hand Cannon", 2))
+	inv.Add(newGear("Slug Box", 1))
+	inv.Add(newWeapon("Ray Gun", 1))
+
+	want := []string{"Hand Cannon", "Ray Gun", "Health Potion", "Slug Box"}
+	if got := names(inv.Items()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Items = %q, want %q", got, want)
+	}
+
+	// A weapon's row in the inventory is its index in Weapons
+	for i, w := range inv.Weapons() {
+		if inv.Items()[i] != w {
+			t.Errorf("row %d is %s, want weapon %s", i, inv.Items()[i].Name(), w.Name())
+		}
+	}
+	var gears []string
+	for _, g := range inv.Gear() {
+		gears = append(gears, g.Name())
+	}
+	if !reflect.DeepEqual(gears, []string{"Health Potion", "Slug Box"}) {
+		t.Errorf("Gear = %q", gears)
+	}
+}
+
+func TestInventoryRemoveFind(t *testing.T) {
+	inv := NewInventory(4)
+	cannon := newWeapon("Hand Cannon", 2)
+	potion := newGear("Health Potion", 1)
+	inv.Add(cannon)
+	inv.Add(potion)
+
+	isGear := func(item dropitem.Item) bool { return item.Kind() == dropitem.KindGear }
+	if got := inv.Find(isGear); got != potion {
+		t.Errorf("Find = %v, want %v", got, potion)
+	}
+
+	// Only the item itself is removed, not another with the same name
+	if inv.Remove(newGear("Health Potion", 1)) {
+		t.Error("Remove of an item not carried reported true")
+	}
+	if !inv.Remove(potion) {
+		t.Error("Remove of a carried item reported false")
+	}
+	if inv.Remove(potion) {
+		t.Error("second Remove reported true")
+	}
+	if got := inv.Find(isGear); got != nil {
+		t.Errorf("Find after Remove = %v, want nil", got)
+	}
+	if got := inv.Used(); got != 2 {
+		t.Errorf("Used = %d, want 2", got)
+	}
+}
+
+func TestInventoryJSON(t *testing.T) {
+	inv := NewInventory(5)
+	cannon := newWeapon("Hand Cannon", 2)
+	cannon.Jammed = true
+	cannon.Ammo = 3
+	kit := &gear.Gear{GearName: "Grenade", SlotCount: 1, GearTypeName: "Explosive", DamageType: "blast", SingleUse: true}
+	inv.Add(newGear("Health Potion", 1))
+	inv.Add(cannon)
+	inv.Add(kit)
+
+	data, err := json.Marshal(inv)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var saved struct {
+		Items []struct {
+			Kind string `json:"kind"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, item := range saved.Items {
+		kinds = append(kinds, item.Kind)
+	}
+	if want := []string{dropitem.KindWeapon, dropitem.KindGear, dropitem.KindGear}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("saved kinds = %q, want %q", kinds, want)
+	}
+
+	var loaded Inventory
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if loaded.Capacity != inv.Capacity {
+		t.Errorf("Capacity = %d, want %d", loaded.Capacity, inv.Capacity)
+	}
+	if !reflect.DeepEqual(loaded.Items(), inv.Items()) {
+		t.Errorf("loaded %q, want %q", names(loaded.Items()), names(inv.Items()))
+	}
+	if _, ok := loaded.Items()[0].(*weapon.Weapon); !ok {
+		t.Errorf("item 0 loaded as %T, want *weapon.Weapon", loaded.Items()[0])
+	}
+	if _, ok := loaded.Items()[2].(*gear.Gear); !ok {
+		t.Errorf("item 2 loaded as %T, want *gear.Gear", loaded.Items()[2])
+	}
+
+	empty, err := json.Marshal(NewInventory(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(empty) != `{"capacity":4,"items":[]}` {
+		t.Errorf("empty inventory saved as %s", empty)
+	}
+
+	if err := json.Unmarshal([]byte(`{"capacity":4,"items":[{"kind":"implant","item":{}}]}`), &loaded); err == nil {
+		t.Error("Unmarshal of an unknown kind succeeded")
+	}
+}
+
+func TestRemoveItemKeepsActiveWeapon(t *testing.T) {
+	tests := []struct {
+		name   string
+		active int    // index of the weapon in hand
+		remove string // item to remove
+		want   string // weapon in hand afterwards
+	}{
+		{"weapon before the one in hand", 2, "Hand Cannon", "Alien Blade"},
+		{"weapon after the one in hand", 0, "Alien Blade", "Hand Cannon"},
+		{"weapon in hand", 1, "Ray Gun", "Hand Cannon"},
+		{"last weapon in hand", 2, "Alien Blade", "Hand Cannon"},
+		{"gear", 2, "Health Potion", "Alien Blade"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useStore(t)
+			p, err := NewPlayer("Quartermaster", Marine, 60, 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Inventory.Capacity = 10
+			for _, item := range []dropitem.Item{
+				newWeapon("Hand Cannon", 2), newGear("Health Potion", 1), newWeapon("Ray Gun", 1), newWeapon("Alien Blade", 2),
+			} {
+				if err := p.EquipItem(item); err != nil {
+					t.Fatalf("EquipItem: %v", err)
+				}
+			}
+			if err := p.SelectWeapon(tt.active); err != nil {
+				t.Fatal(err)
+			}
+
+			item := p.Inventory.Find(func(i dropitem.Item) bool { return i.Name() == tt.remove })
+			if err := p.RemoveItem(item); err != nil {
+				t.Fatalf("RemoveItem: %v", err)
+			}
+			if p.Inventory.Find(func(i dropitem.Item) bool { return i == item }) != nil {
+				t.Errorf("%s is still carried", tt.remove)
+			}
+			if w := p.CurrentWeapon(); w == nil || w.Name() != tt.want {
+				t.Errorf("CurrentWeapon = %v, want %s", w, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnequipWeapon(t *testing.T) {
+	useStore(t)
+	p, err := NewPlayer("Quartermaster", Marine, 60, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.EquipItem(newWeapon("Hand Cannon", 2))
+	p.EquipItem(newWeapon("Ray Gun", 1))
+	p.SelectWeapon(1)
+
+	p.UnequipWeapon()
+	if w := p.CurrentWeapon(); w == nil || w.Name() != "Hand Cannon" {
+		t.Errorf("CurrentWeapon = %v, want Hand Cannon", w)
+	}
+	p.UnequipWeapon()
+	if w := p.CurrentWeapon(); w != nil {
+		t.Errorf("CurrentWeapon = %v, want none", w)
+	}
+}
+
+// failingStore is a store whose player saves fail while fail is set.
+type failingStore struct {
+	store.Store
+	fail bool
+}
+
+func (s *failingStore) SavePlayer(id string, data []byte) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return s.Store.SavePlayer(id, data)
+}
+
+// armedPlayer returns a player carrying a Hand Cannon, a Health Potion and a
+// Ray Gun in six slots, with the Ray Gun in hand, whose saves fail while the
+// returned store's fail is set.
+func armedPlayer(t *testing.T) (*Player, *failingStore) {
+	t.Helper()
+	useStore(t)
+	s := &failingStore{Store: store.Default()}
+	store.SetDefault(s)
+
+	p, err := NewPlayer("Quartermaster", Marine, 60, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Inventory.Capacity = 6
+	for _, item := range []dropitem.Item{newWeapon("Hand Cannon", 2), newGear("Health Potion", 1), newWeapon("Ray Gun", 1)} {
+		if err := p.EquipItem(item); err != nil {
+			t.Fatalf("EquipItem: %v", err)
+		}
+	}
+	p.SelectWeapon(1)
+	return p, s
+}
+
+// checkUnchanged checks that the player still carries what armedPlayer gave
+// them, with the Ray Gun in hand.
+func checkUnchanged(t *testing.T, p *Player) {
+	t.Helper()
+	if got, want := names(p.Inventory.Items()), []string{"Hand Cannon", "Ray Gun", "Health Potion"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("inventory = %q, want %q", got, want)
+	}
+	if w := p.CurrentWeapon(); w == nil || w.Name() != "Ray Gun" {
+		t.Errorf("CurrentWeapon = %v, want Ray Gun", w)
+	}
+}
+
+func TestFailedSaveRollsBack(t *testing.T) {
+	t.Run("EquipItem", func(t *testing.T) {
+		p, s := armedPlayer(t)
+		s.fail = true
+		found := newGear("Slug Box", 1)
+		if err := p.EquipItem(found); err == nil {
+			t.Fatal("EquipItem succeeded")
+		}
+		checkUnchanged(t, p)
+
+		// Taking it again once saves work adds it only once
+		s.fail = false
+		if err := p.EquipItem(found); err != nil {
+			t.Fatalf("EquipItem: %v", err)
+		}
+		if got := p.UsedSlots(); got != 5 {
+			t.Errorf("UsedSlots = %d, want 5", got)
+		}
+	})
+	t.Run("RemoveItem", func(t *testing.T) {
+		p, s := armedPlayer(t)
+		s.fail = true
+		if err := p.RemoveItem(p.CurrentWeapon()); err == nil {
+			t.Fatal("RemoveItem succeeded")
+		}
+		checkUnchanged(t, p)
+	})
+	t.Run("SwapItem", func(t *testing.T) {
+		p, s := armedPlayer(t)
+		s.fail = true
+		if err := p.SwapItem(p.CurrentWeapon(), newWeapon("Alien Blade", 1)); err == nil {
+			t.Fatal("SwapItem succeeded")
+		}
+		checkUnchanged(t, p)
+	})
+	t.Run("SwapItem without room", func(t *testing.T) {
+		p, _ := armedPlayer(t)
+		if err := p.SwapItem(p.CurrentWeapon(), newWeapon("Big Gun", 4)); !errors.Is(err, ErrNoRoom) {
+			t.Fatalf("SwapItem = %v, want ErrNoRoom", err)
+		}
+		checkUnchanged(t, p)
+	})
+}
+
+func TestSwapItem(t *testing.T) {
+	p, _ := armedPlayer(t)
+
+	// A weapon swapped for the one in hand is taken up in its place
+	if err := p.SwapItem(p.CurrentWeapon(), newWeapon("Alien Blade", 1)); err != nil {
+		t.Fatalf("SwapItem: %v", err)
+	}
+	if w := p.CurrentWeapon(); w == nil || w.Name() != "Alien Blade" {
+		t.Errorf("CurrentWeapon = %v, want Alien Blade", w)
+	}
+
+	// Gear swapped for gear leaves the weapon in hand alone
+	potion := p.Inventory.Find(func(i dropitem.Item) bool { return i.Name() == "Health Potion" })
+	if err := p.SwapItem(potion, newGear("Slug Box", 1)); err != nil {
+		t.Fatalf("SwapItem: %v", err)
+	}
+	if got, want := names(p.Inventory.Items()), []string{"Hand Cannon", "Alien Blade", "Slug Box"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("inventory = %q, want %q", got, want)
+	}
+	if w := p.CurrentWeapon(); w == nil || w.Name() != "Alien Blade" {
+		t.Errorf("CurrentWeapon = %v, want Alien Blade", w)
+	}
+
+	loaded, err := LoadPlayer("Quartermaster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(loaded.Inventory.Items()); !reflect.DeepEqual(got, names(p.Inventory.Items())) {
+		t.Errorf("saved inventory = %q", got)
+	}
+}
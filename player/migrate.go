@@ -0,0 +1,216 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"spacejunk3000/store"
+)
+
+// migrations upgrade a saved player one version at a time: migrations[i]
+// turns a version i save into a version i+1 save. Saves from before the
+// format was versioned are version 0. To change the format, append a step.
+var migrations = []func(doc map[string]any) error{
+	0: migrateUnversioned,
+	1: migrateWoundSources,
+	2: migrateShield,
+	3: migrateInventory,
+}
+
+// SaveVersion is the version of the save format written by SavePlayer.
+var SaveVersion = len(migrations)
+
+// migrate upgrades a saved player to SaveVersion. It returns the upgraded
+// save and the version it started at.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("error unmarshaling player data: %v", err)
+	}
+
+	version := 0
+	if v, ok := number(doc["version"]); ok {
+		version = v
+	}
+	if version > SaveVersion {
+		return nil, version, fmt.Errorf("player data is version %d, newer than this game's version %d", version, SaveVersion)
+	}
+	if version == SaveVersion {
+		return data, version, nil
+	}
+
+	for v := version; v < SaveVersion; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, version, fmt.Errorf("error migrating player data from version %d: %v", v, err)
+		}
+		doc["version"] = v + 1
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, version, fmt.Errorf("error marshaling player data: %v", err)
+	}
+	return upgraded, version, nil
+}
+
+// number returns a number from a save being migrated. Numbers read from the
+// save are float64, but numbers set by an earlier step are int.
+func number(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+	return 0, false
+}
+
+// backupSave keeps a copy of a save as it was before being migrated.
+func backupSave(id string, version int, data []byte) error {
+	if err := store.Default().BackupPlayer(id, version, data); err != nil {
+		return fmt.Errorf("error backing up player data: %v", err)
+	}
+	return nil
+}
+
+// migrateUnversioned fills in what saves from before versioning can be
+// missing. The weapon field held a single weapon before players could carry
+// several, and older saves have no health record or crew die.
+func migrateUnversioned(doc map[string]any) error {
+	switch w := doc["weapon"].(type) {
+	case map[string]any:
+		doc["weapon"] = []any{w}
+	case nil:
+		delete(doc, "weapon")
+	}
+
+	// A missing alive flag would reset the player on their next game
+	if _, ok := doc["alive"]; !ok {
+		doc["alive"] = true
+	}
+
+	if slots, _ := number(doc["max_slots"]); slots <= 0 {
+		doc["max_slots"] = 4
+	}
+	countSlots(doc, "weapon", "weapon_slots")
+	countSlots(doc, "gear", "gear_slots")
+
+	// Rebuild the health record from the player's health, with lost
+	// points marked as damaged
+	if record, _ := doc["health_record"].([]any); len(record) != 12 {
+		health := 12
+		if h, ok := number(doc["health"]); ok {
+			health = min(max(h, 0), 12)
+		}
+		boxes := make([]any, 12)
+		for i := range boxes {
+			if i < health {
+				boxes[i] = "-"
+			} else {
+				boxes[i] = "\\"
+			}
+		}
+		doc["health_record"] = boxes
+	}
+
+	// Stats and crew dice come from the character type
+	charType, _ := doc["type"].(string)
+	if _, ok := doc["stats"].(map[string]any); !ok {
+		if stats, err := GetCharacterStats(CharacterType(charType)); err == nil {
+			doc["stats"] = stats
+		}
+	}
+	if dice, _ := doc["crew_dice"].(map[string]any); dice["die_side_1"] == nil || dice["die_side_1"] == "" {
+		if dice, err := GetCrewDice(CharacterType(charType)); err == nil {
+			doc["crew_dice"] = dice
+		}
+	}
+
+	return nil
+}
+
+// countSlots sets a missing slot count from the items the save holds.
+func countSlots(doc map[string]any, items, count string) {
+	if _, ok := doc[count]; ok {
+		return
+	}
+	total := 0
+	list, _ := doc[items].([]any)
+	for _, item := range list {
+		if item, ok := item.(map[string]any); ok {
+			slots, _ := number(item["slots"])
+			total += slots
+		}
+	}
+	doc[count] = total
+}
+
+// migrateWoundSources adds the wound sources, which version 1 saves did not
+// keep, and brings health back in line with the health record: damage used
+// to mark the record without lowering health.
+func migrateWoundSources(doc map[string]any) error {
+	record, _ := doc["health_record"].([]any)
+	sources := make([]any, len(record))
+	healthy := 0
+	for i, box := range record {
+		sources[i] = []any{}
+		if box == BoxWounded {
+			sources[i] = []any{unknownSource}
+		} else {
+			healthy++
+		}
+	}
+	doc["wound_sources"] = sources
+
+	if h, ok := number(doc["health"]); !ok || h > healthy {
+		doc["health"] = healthy
+	}
+	return nil
+}
+
+// migrateShield adds the shield, and wounds every box of a player whose
+// health was set to zero without their health record being marked, as
+// quitting used to do.
+func migrateShield(doc map[string]any) error {
+	doc["shield"] = 0
+
+	if h, _ := number(doc["health"]); h > 0 {
+		return nil
+	}
+	record, _ := doc["health_record"].([]any)
+	sources, _ := doc["wound_sources"].([]any)
+	for i, box := range record {
+		if box == BoxWounded || i >= len(sources) {
+			continue
+		}
+		record[i] = BoxWounded
+		list, _ := sources[i].([]any)
+		sources[i] = append(list, unknownSource)
+	}
+	return nil
+}
+
+// migrateInventory moves the player's weapons and gear into one inventory.
+// Version 3 saves kept them in separate lists, each with its own slot count.
+func migrateInventory(doc map[string]any) error {
+	capacity := defaultCapacity
+	if slots, _ := number(doc["max_slots"]); slots > 0 {
+		capacity = slots
+	}
+
+	items := []any{}
+	// The old field names are also the kinds of item they held
+	for _, kind := range []string{"weapon", "gear"} {
+		saved, _ := doc[kind].([]any)
+		for _, item := range saved {
+			if item != nil {
+				items = append(items, map[string]any{"kind": kind, "item": item})
+			}
+		}
+	}
+	doc["inventory"] = map[string]any{"capacity": capacity, "items": items}
+
+	for _, field := range []string{"weapon", "weapon_slots", "gear", "gear_slots", "max_slots"} {
+		delete(doc, field)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package player
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"spacejunk3000/combat"
+	"spacejunk3000/store"
+	"testing"
+)
+
+// useStore makes a files store in a temporary directory the default store
+// for the test, and returns the directory.
+func useStore(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	old := store.Default()
+	store.SetDefault(store.NewFiles(dir))
+	t.Cleanup(func() { store.SetDefault(old) })
+	return dir
+}
+
+// saveFixture stores a save from testdata under the given key, as an older
+// version of the game would have written it.
+func saveFixture(t *testing.T, fixture, key string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Default().SavePlayer(key, data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// record returns a health record of the given healthy and wounded boxes,
+// e.g. record("\\\\-") for two wounded boxes and one healthy one.
+func record(boxes string) []string {
+	var r []string
+	for _, b := range boxes {
+		r = append(r, string(b))
+	}
+	return r
+}
+
+// sources returns the wound sources of a record of n boxes, with the given
+// sources for the boxes listed and none for the rest.
+func sources(n int, wounded map[int][]string) [][]string {
+	s := make([][]string, n)
+	for i, list := range wounded {
+		s[i] = list
+	}
+	return s
+}
+
+func TestLoadPlayerMigrates(t *testing.T) {
+	unknown := []string{unknownSource}
+	tests := []struct {
+		fixture  string
+		name     string
+		legacy   bool // saved under the player's alias, from before player IDs
+		version  int
+		capacity int
+		items    []string // names of the inventory's items, in order
+		active   string   // the weapon in hand
+		health   int
+		record   []string
+		sources  [][]string
+		charType CharacterType
+	}{
+		{
+			fixture: "v0.json", name: "Old Timer", legacy: true, version: 0,
+			capacity: 4, items: []string{"Hand Cannon", "Health Potion"}, active: "Hand Cannon",
+			health: 9, record: record(`\\\---------`),
+			sources:  sources(12, map[int][]string{0: unknown, 1: unknown, 2: unknown}),
+			charType: Pirate,
+		},
+		{
+			fixture: "v0-single-weapon.json", name: "First Player", version: 0,
+			capacity: 4, items: []string{"Alien Blade"}, active: "Alien Blade",
+			health: 5, record: record(`-----\\\\\\\`),
+			sources:  sources(12, map[int][]string{5: unknown, 6: unknown, 7: unknown, 8: unknown, 9: unknown, 10: unknown, 11: unknown}),
+			charType: Marine,
+		},
+		{
+			fixture: "v1.json", name: "Version One", version: 1,
+			capacity: 5, items: []string{"Alien Blade", "Ray Gun"}, active: "Alien Blade",
+			health: 10, record: record(`\\----------`),
+			sources:  sources(12, map[int][]string{0: unknown, 1: unknown}),
+			charType: Marine,
+		},
+		{
+			fixture: "v2.json", name: "Version Two", version: 2,
+			capacity: 4, items: []string{"Ray Gun", "Slug Box"}, active: "Ray Gun",
+			health: 0, record: record(`\\\\\\\\\\\\`),
+			sources: sources(12, map[int][]string{
+				0: {"Security Drone"}, 1: unknown, 2: unknown, 3: unknown, 4: unknown, 5: unknown,
+				6: unknown, 7: unknown, 8: unknown, 9: unknown, 10: unknown, 11: unknown,
+			}),
+			charType: Spy,
+		},
+		{
+			fixture: "v3.json", name: "Version Three", version: 3,
+			capacity: 6, items: []string{"Hand Cannon", "Ray Gun", "Health Potion", "Slug Box"}, active: "Ray Gun",
+			health: 10, record: record(`\----------\`),
+			sources:  sources(12, map[int][]string{0: {"Patroling Guards"}, 11: {"Security Drone", unknownSource}}),
+			charType: Smuggler,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			dir := useStore(t)
+			id := PlayerID(tt.name)
+			key := id
+			if tt.legacy {
+				key = tt.name
+			}
+			original := saveFixture(t, tt.fixture, key)
+
+			p, err := LoadPlayer(tt.name)
+			if err != nil {
+				t.Fatalf("LoadPlayer: %v", err)
+			}
+			checkMigrated(t, p, tt.capacity, tt.items, tt.active, tt.health, tt.record, tt.sources)
+			if p.Type != tt.charType {
+				t.Errorf("Type = %q, want %q", p.Type, tt.charType)
+			}
+			if stats, _ := GetCharacterStats(tt.charType); p.Stats != stats {
+				t.Errorf("Stats = %+v, want %+v", p.Stats, stats)
+			}
+			for _, face := range p.CrewDice.Faces() {
+				if _, err := combat.ParseFace(face); err != nil {
+					t.Errorf("crew die face: %v", err)
+				}
+			}
+
+			// The save as it was is kept, and the upgraded one replaces it
+			backup, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("u-%s.json.v%d.bak", id, tt.version)))
+			if err != nil {
+				t.Fatalf("no backup: %v", err)
+			}
+			if !bytes.Equal(backup, original) {
+				t.Errorf("backup does not match the original save")
+			}
+			saved, err := store.Default().LoadPlayer(id)
+			if err != nil {
+				t.Fatalf("upgraded save: %v", err)
+			}
+			if _, version, _ := migrate(saved); version != SaveVersion {
+				t.Errorf("upgraded save is version %d, want %d", version, SaveVersion)
+			}
+			if tt.legacy {
+				if _, err := store.Default().LoadPlayer(tt.name); !errors.Is(err, store.ErrNotFound) {
+					t.Errorf("save under the alias was not removed: %v", err)
+				}
+			}
+
+			// Loading the upgraded save changes nothing
+			again, err := LoadPlayer(tt.name)
+			if err != nil {
+				t.Fatalf("second LoadPlayer: %v", err)
+			}
+			checkMigrated(t, again, tt.capacity, tt.items, tt.active, tt.health, tt.record, tt.sources)
+		})
+	}
+}
+
+// checkMigrated checks a loaded player's inventory and health.
+func checkMigrated(t *testing.T, p *Player, capacity int, items []string, active string, health int, record []string, sources [][]string) {
+	t.Helper()
+	if p.Version != SaveVersion {
+		t.Errorf("Version = %d, want %d", p.Version, SaveVersion)
+	}
+	if p.Inventory.Capacity != capacity {
+		t.Errorf("Inventory.Capacity = %d, want %d", p.Inventory.Capacity, capacity)
+	}
+	var names []string
+	for _, item := range p.Inventory.Items() {
+		names = append(names, item.Name())
+	}
+	if !reflect.DeepEqual(names, items) {
+		t.Errorf("inventory = %q, want %q", names, items)
+	}
+	if w := p.CurrentWeapon(); w == nil || w.Name() != active {
+		t.Errorf("CurrentWeapon = %v, want %s", w, active)
+	}
+	if p.Health != health {
+		t.Errorf("Health = %d, want %d", p.Health, health)
+	}
+	if !reflect.DeepEqual(p.HealthRecord, record) {
+		t.Errorf("HealthRecord = %q, want %q", p.HealthRecord, record)
+	}
+	for i := range sources {
+		if len(sources[i]) == 0 && len(p.WoundSources[i]) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(p.WoundSources[i], sources[i]) {
+			t.Errorf("WoundSources[%d] = %q, want %q", i, p.WoundSources[i], sources[i])
+		}
+	}
+	if len(p.WoundSources) != len(p.HealthRecord) {
+		t.Errorf("%d wound sources for %d boxes", len(p.WoundSources), len(p.HealthRecord))
+	}
+}
+
+func TestLoadPlayerNewerVersion(t *testing.T) {
+	useStore(t)
+	id := PlayerID("Time Traveller")
+	future := []byte(`{"version":99,"name":"Time Traveller"}`)
+	if err := store.Default().SavePlayer(id, future); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadPlayer("Time Traveller")
+	if err == nil {
+		t.Fatal("LoadPlayer of a newer save succeeded")
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		t.Errorf("LoadPlayer error %v would be taken for a new player", err)
+	}
+	saved, _ := store.Default().LoadPlayer(id)
+	if !bytes.Equal(saved, future) {
+		t.Errorf("newer save was changed to %s", saved)
+	}
+}
+
+func TestLoadPlayerNotFound(t *testing.T) {
+	useStore(t)
+	if _, err := LoadPlayer("Nobody"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("LoadPlayer error = %v, want store.ErrNotFound", err)
+	}
+}
@@ -2,32 +2,38 @@ package player
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"spacejunk3000/door"
+	"spacejunk3000/dropitem"
 	"spacejunk3000/gear"
 	"spacejunk3000/implant"
+	"spacejunk3000/safefile"
+	"spacejunk3000/store"
 	"spacejunk3000/weapon"
 )
 
 // Define the Player struct with exported Inventory field
 type Player struct {
-	Name         string           `json:"name"`   // Exported field
-	Type         CharacterType    `json:"type"`   // Exported field
-	Health       int              `json:"health"` // Exported field
-	HealthRecord []string         `json:"health_record"`
-	Stats        Stats            `json:"stats"`            // Exported field
-	Alive        bool             `json:"alive"`            // Unexported field
-	TimeLeft     int              `json:"-"`                // Unexported field
-	Emulation    int              `json:"-"`                // Unexported field
-	NodeNum      int              `json:"-"`                // Unexported field
-	Weapons      []*weapon.Weapon `json:"weapon,omitempty"` // Include a field for the weapon
-	WeaponSlots  int              `json:"weapon_slots"`     // Number of filled weapon slots
-	Gear         []*gear.Gear     `json:"gear"`             // Include a field for the gear
-	GearSlots    int              `json:"gear_slots"`       // Number of filled item slots
-	MaxSlots     int              `json:"max_slots"`        // Maximum number of total slots
-	CrewDice     CrewDice         `json:"crew_dice"`
-	Implant      implant.Implant  `json:"implant"` // Include a field for the implants
+	Version      int             `json:"version"` // Save format version, see SaveVersion
+	ID           string          `json:"id"`      // Safe file name for the player, see PlayerID
+	Name         string          `json:"name"`    // Exported field
+	Type         CharacterType   `json:"type"`    // Exported field
+	Health       int             `json:"health"`  // Boxes of the health record not wounded, see health.go
+	HealthRecord []string        `json:"health_record"`
+	WoundSources [][]string      `json:"wound_sources"` // What wounded each health record box, oldest first
+	Shield       int             `json:"shield"`        // Damage absorbed before the health record, for this encounter
+	Stats        Stats           `json:"stats"`         // Exported field
+	Alive        bool            `json:"alive"`         // Unexported field
+	TimeLeft     int             `json:"-"`             // Unexported field
+	Emulation    int             `json:"-"`             // Unexported field
+	NodeNum      int             `json:"-"`             // Unexported field
+	Inventory    *Inventory      `json:"inventory"`     // Weapons and gear, sharing the player's slots
+	ActiveWeapon int             `json:"active_weapon"` // Index in Inventory.Weapons of the weapon in hand
+	CrewDice     CrewDice        `json:"crew_dice"`
+	Implant      implant.Implant `json:"implant"` // Include a field for the implants
 
 }
 
@@ -59,36 +65,37 @@ type CrewDice struct {
 	DieSide6 string `json:"die_side_6"`
 }
 
-func PrintPlayerInventory(player *Player) {
-	// Create a slice to hold all items (weapons and gear)
-	items := make([]interface{}, 0, player.MaxSlots)
+// Faces returns the six sides of the crew die in order.
+func (c CrewDice) Faces() []string {
+	return []string{c.DieSide1, c.DieSide2, c.DieSide3, c.DieSide4, c.DieSide5, c.DieSide6}
+}
 
-	// Append weapons to the items slice
-	for _, weapon := range player.Weapons {
-		items = append(items, weapon)
-	}
+// PrintPlayerInventory prints one row per inventory slot to the terminal.
+func PrintPlayerInventory(t *door.Terminal, player *Player) {
+	// Weapons come first, so a weapon's row matches its index in Weapons
+	items := player.Inventory.Items()
+	inHand := player.CurrentWeapon()
 
-	// Append gear to the items slice
-	for _, gear := range player.Gear {
-		items = append(items, gear)
-	}
-
-	// Print items
-	for i := 0; i < player.MaxSlots; i++ {
-		if i < len(items) {
-			// Print actual item
-			switch item := items[i].(type) {
-			case *weapon.Weapon:
-				fmt.Printf("%s%d %s%-14s %s%-2d %s%-9s %s%-4d %-4d\r\n", door.BlackHi, i+1, door.CyanHi, item.Name, door.Reset, item.Slots, door.Cyan, item.WeaponTypeName, door.Reset, item.Ammo, item.FireRate)
-			case *gear.Gear:
-				fmt.Printf("%d %-14s %-2d %-9s %-4s %-4s\r\n", i+1, item.Name, item.Slots, item.GearTypeName, "-", "-")
-			}
-		} else {
+	for i := 0; i < player.Inventory.Capacity; i++ {
+		if i >= len(items) {
 			// Print empty row
-			fmt.Printf("%s%d %-14s %-2s %-9s %-4s %-4s%s\r\n", door.BlackHi, i+1, "-", "-", "-", "-", "-", door.Reset)
+			t.Printf("%s%d %-14s %-2s %-9s %-4s %-4s%s\r\n", door.BlackHi, i+1, "-", "-", "-", "-", "-", door.Reset)
+			continue
 		}
-	}
 
+		item := items[i]
+		row := item.Row()
+		// The weapon in hand is marked beside its number
+		mark := " "
+		if inHand != nil && item == dropitem.Item(inHand) {
+			mark = door.YellowHi + "*"
+		}
+		name, ammo := door.CyanHi, door.Reset
+		if row.Alert {
+			name, ammo = door.RedHi, door.RedHi
+		}
+		t.Printf("%s%d%s%s%-14s %s%-2d %s%-9s %s%-4s %-4s%s\r\n", door.BlackHi, i+1, mark, name, item.Name(), door.Reset, item.Slots(), door.Cyan, row.Type, ammo, row.Ammo, row.Fire, door.Reset)
+	}
 }
 
 // NewPlayer creates a new player instance with the provided attributes.
@@ -102,29 +109,26 @@ func NewPlayer(name string, charType CharacterType, timeLeft int, nodeNum int, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to get crew dice: %v", err)
 	}
-	// Initialize the health record with all "-" for full health
-	healthRecord := make([]string, 12)
-	for i := range healthRecord {
-		healthRecord[i] = "-"
+	id, err := LookupID(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up player ID: %v", err)
 	}
-
 	return &Player{
+		Version:      SaveVersion,
+		ID:           id,
 		Name:         name,
 		Type:         charType,
-		Health:       12,
-		HealthRecord: healthRecord,
+		Health:       defaultMaxHealth,
+		HealthRecord: newHealthRecord(defaultMaxHealth),
+		WoundSources: make([][]string, defaultMaxHealth),
 		Stats:        stats,
 		TimeLeft:     timeLeft,
 		NodeNum:      nodeNum,
 		CrewDice:     dice,
 		Emulation:    emulation,
 		Alive:        true,
-		MaxSlots:     4,                         // Default value, can be modified if needed
-		Weapons:      make([]*weapon.Weapon, 0), // Initialize the weapons slice
-		WeaponSlots:  0,                         // Initialize the weapon slots
-		Implant:      implant.Implant{},         // Initialize the implant
-		Gear:         make([]*gear.Gear, 0),     // Initialize the gear slice
-		GearSlots:    0,                         // Initialize the gear slots
+		Inventory:    NewInventory(defaultCapacity), // Default value, can be modified if needed
+		Implant:      implant.Implant{},             // Initialize the implant
 
 	}, nil
 }
@@ -154,12 +158,12 @@ func GetCrewDice(charType CharacterType) (CrewDice, error) {
 	switch charType {
 	case Pirate: // Miller
 		return CrewDice{
-			DieSide1: "dexerity",
+			DieSide1: "dexterity",
 			DieSide2: "strength",
-			DieSide3: "double dexerity",
-			DieSide4: "dexerity",
+			DieSide3: "double dexterity",
+			DieSide4: "dexterity",
 			DieSide5: "intelligence",
-			DieSide6: "ddouble strength",
+			DieSide6: "double strength",
 		}, nil
 	case Marine: // Smith
 		return CrewDice{
@@ -213,6 +217,15 @@ func GetCrewDice(charType CharacterType) (CrewDice, error) {
 
 // SavePlayer serializes the player data to JSON and writes it to a file.
 func SavePlayer(p *Player) error {
+	if p.ID == "" {
+		id, err := LookupID(p.Name)
+		if err != nil {
+			return err
+		}
+		p.ID = id
+	}
+	p.Version = SaveVersion
+
 	// Marshal player data to JSON
 	data, err := json.Marshal(p)
 	if err != nil {
@@ -222,9 +235,10 @@ func SavePlayer(p *Player) error {
 	// Print out the serialized player data for debugging
 	// fmt.Println("Serialized player data:", string(data))
 
-	// Filename based on player name, which is the unique ID
-	filename := fmt.Sprintf("data/u-%s.json", p.Name)
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := registerID(p.Name, p.ID); err != nil {
+		return err
+	}
+	if err := store.Default().SavePlayer(p.ID, data); err != nil {
 		return fmt.Errorf("error writing player data to file: %v", err)
 	}
 
@@ -232,130 +246,240 @@ func SavePlayer(p *Player) error {
 }
 
 // LoadPlayer deserializes player data from a JSON file.
+// A save from before players had IDs is moved to its new file on first load,
+// and a save in an older format is backed up and upgraded.
 func LoadPlayer(name string) (*Player, error) {
-	filename := fmt.Sprintf("data/u-%s.json", name)
-	data, err := os.ReadFile(filename)
+	id, err := LookupID(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := store.Default().LoadPlayer(id)
+	if errors.Is(err, store.ErrNotFound) {
+		data, err = migrateLegacy(name, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading player data file: %w", err) // store.ErrNotFound means a new player
+	}
+
+	// Upgrade saves written by older versions of the game
+	upgraded, version, err := migrate(data)
 	if err != nil {
-		return nil, fmt.Errorf("error reading player data file: %v", err) // File not found could mean new player
+		return nil, err
 	}
+	if version < SaveVersion {
+		if err := backupSave(id, version, data); err != nil {
+			return nil, err
+		}
+		data = upgraded
+	}
+
 	var p Player
 	if err := json.Unmarshal(data, &p); err != nil {
 		return nil, fmt.Errorf("error unmarshaling player data: %v", err)
 	}
+	p.ID = id
+	p.syncHealth()
+	if p.Inventory == nil {
+		p.Inventory = NewInventory(defaultCapacity)
+	}
+	if version < SaveVersion {
+		if err := SavePlayer(&p); err != nil {
+			return nil, err
+		}
+	} else if err := registerID(p.Name, p.ID); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
+// lockFilename returns the name the player's session lock is taken on, which
+// the store keeps beside the player.
+func lockFilename(id string) string {
+	return store.Default().LockPath(id)
+}
+
+// InUseError is returned by LockPlayer when the player is already in a game.
+type InUseError struct {
+	Name string
+	Node int // the node playing, or 0 if unknown
+}
+
+func (e *InUseError) Error() string {
+	return fmt.Sprintf("%s is already playing on node %d", e.Name, e.Node)
+}
+
+// LockPlayer locks the named player's save for the session on the given node,
+// so the same player cannot be loaded on two nodes at once. The lock is
+// released by calling Unlock on the result. If another node holds it the
+// error is an *InUseError.
+func LockPlayer(name string, node int) (*safefile.Lock, error) {
+	id, err := LookupID(name)
+	if err != nil {
+		return nil, err
+	}
+	path := lockFilename(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	lock, err := safefile.LockFile(path, fmt.Sprintf("node %d", node))
+	var locked *safefile.LockedError
+	if errors.As(err, &locked) {
+		inUse := &InUseError{Name: name}
+		fmt.Sscanf(locked.Owner, "node %d", &inUse.Node)
+		return nil, inUse
+	}
+	return lock, err
+}
+
 func ResetPlayer(p *Player) {
-	p.Health = 12
+	p.resetHealth()
 	p.Alive = true
-	p.Weapons = nil
-	p.WeaponSlots = 0
-	p.GearSlots = 0
-	p.MaxSlots = 4
+	p.Inventory = NewInventory(defaultCapacity)
+	p.ActiveWeapon = 0
 
 	// Additional reset logic as needed
 }
 
-// EquipGear equips a gear to the player if there are available slots.
-func (p *Player) EquipGear(g *gear.Gear) error {
-	// Check if there are enough gear slots to equip the gear
-	if p.GearSlots+g.Slots > p.MaxSlots {
-		return fmt.Errorf("cannot carry that much")
-	}
+// EquipItem puts a weapon or piece of gear in the player's inventory if
+// there are available slots.
+func (p *Player) EquipItem(item dropitem.Item) error {
+	restore := p.checkpoint()
 
-	// Equip the gear to the player
-	p.Gear = append(p.Gear, g)
-	p.GearSlots += g.Slots
+	// Weapons and gear share the player's slots
+	if err := p.Inventory.Add(item); err != nil {
+		return err
+	}
 
-	// Save the player's data after equipping the weapon
+	// Save the player's data after equipping the item
 	if err := SavePlayer(p); err != nil {
+		restore()
 		return fmt.Errorf("failed to save player: %v", err)
 	}
 
 	return nil
 }
 
-// EquipWeapon equips a weapon to the player if there are available slots.
-func (p *Player) EquipWeapon(w *weapon.Weapon) error {
-	// Check if there are enough weapon slots to equip the weapon
-	if p.WeaponSlots+w.Slots > p.MaxSlots {
-		return fmt.Errorf("cannot carry that much")
-	}
-
-	// Equip the weapon to the player
-	p.Weapons = append(p.Weapons, w)
-	p.WeaponSlots += w.Slots
+// RemoveItem takes an item out of the player's inventory, e.g. when they
+// drop it or it is used up. If it was the weapon in their hand, they take up
+// their first weapon instead.
+func (p *Player) RemoveItem(item dropitem.Item) error {
+	restore := p.checkpoint()
+	p.takeOut(item)
 
-	// Save the player's data after equipping the weapon
 	if err := SavePlayer(p); err != nil {
+		restore()
 		return fmt.Errorf("failed to save player: %v", err)
 	}
-
 	return nil
 }
 
-// UnequipWeapon unequips the player's weapon.
-func (p *Player) UnequipWeapon() {
-	if len(p.Weapons) > 0 {
-		p.Weapons = p.Weapons[:len(p.Weapons)-1]
-		p.WeaponSlots--
+// SwapItem leaves an item the player carries behind and takes another in its
+// place. A weapon swapped for the one in their hand takes its place in hand.
+// If any step fails, the inventory is left as it was.
+func (p *Player) SwapItem(item, found dropitem.Item) error {
+	restore := p.checkpoint()
+	inHand := item == dropitem.Item(p.CurrentWeapon())
+
+	p.takeOut(item)
+	if err := p.Inventory.Add(found); err != nil {
+		restore()
+		return err
 	}
-	// Save the player's data after equipping the weapon
+	if found.Kind() == dropitem.KindWeapon && inHand {
+		if err := p.SelectWeapon(len(p.Weapons()) - 1); err != nil {
+			restore()
+			return err
+		}
+	}
+
 	if err := SavePlayer(p); err != nil {
-		fmt.Printf("failed to save player: %v", err)
+		restore()
+		return fmt.Errorf("failed to save player: %v", err)
 	}
+	return nil
+}
 
+// takeOut removes an item from the inventory, keeping the weapon in hand.
+func (p *Player) takeOut(item dropitem.Item) {
+	if w, ok := item.(*weapon.Weapon); ok {
+		p.dropWeapon(w)
+	} else {
+		p.Inventory.Remove(item)
+	}
 }
 
-// AdjustHealth updates the player's health and modifies the health record.
-// Pass a positive number to heal, or a negative number to deal damage.
-func (p *Player) AdjustHealth(amount int) {
-	for i := 0; i < len(p.HealthRecord) && amount != 0; i++ {
-		if amount > 0 && p.HealthRecord[i] == "\\" {
-			p.HealthRecord[i] = "/"
-			amount--
-		} else if amount < 0 && p.HealthRecord[i] == "-" {
-			p.HealthRecord[i] = "\\"
-			amount++
-		}
+// checkpoint returns a function that puts the player's inventory and the
+// weapon in their hand back as they are now, for when a change to them
+// cannot be completed or saved.
+func (p *Player) checkpoint() (restore func()) {
+	items := append([]dropitem.Item(nil), p.Inventory.items...)
+	capacity, active := p.Inventory.Capacity, p.ActiveWeapon
+	return func() {
+		p.Inventory.items = items
+		p.Inventory.Capacity = capacity
+		p.ActiveWeapon = active
 	}
-	// Update the actual Health value accordingly
-	p.Health += amount
 }
 
-// DisplayHealthRecord outputs the player's health record as a string.
-func (p *Player) DisplayHealthRecord() string {
-	// Top part of the medical record
-	record := "\r\n┌──────────────────────────────┐\r\n│ MEDICAL RECORD               │\n"
-
-	// Adding the health points with appropriate symbols
-	for i := 12; i > 0; i-- {
-		line := fmt.Sprintf("│ %2d │ ", i)
-		for j := 0; j < 12; j++ {
-			if j < p.Health {
-				// Check the HealthRecord for visual representation of health points
-				line += p.HealthRecord[j] + " "
-			} else {
-				line += "  " // Empty space for lost health points
+// dropWeapon takes a weapon out of the inventory and keeps ActiveWeapon
+// pointing at the weapon in hand.
+func (p *Player) dropWeapon(w *weapon.Weapon) {
+	for i, item := range p.Weapons() {
+		if item == w {
+			p.Inventory.Remove(w)
+			if i < p.ActiveWeapon {
+				p.ActiveWeapon--
+			} else if i == p.ActiveWeapon {
+				p.ActiveWeapon = 0
 			}
+			return
 		}
-		record += line + "│\r\n"
 	}
+}
+
+// Weapons returns the weapons the player carries.
+func (p *Player) Weapons() []*weapon.Weapon {
+	return p.Inventory.Weapons()
+}
 
-	// Bottom part of the medical record
-	record += "└──────────────────────────────┘"
+// Gear returns the gear the player carries.
+func (p *Player) Gear() []*gear.Gear {
+	return p.Inventory.Gear()
+}
+
+// UsedSlots returns the slots taken up by the player's weapons and gear.
+func (p *Player) UsedSlots() int {
+	return p.Inventory.Used()
+}
+
+// CurrentWeapon returns the weapon in the player's hand, or nil if they
+// have none.
+func (p *Player) CurrentWeapon() *weapon.Weapon {
+	weapons := p.Weapons()
+	if p.ActiveWeapon < 0 || p.ActiveWeapon >= len(weapons) {
+		return nil
+	}
+	return weapons[p.ActiveWeapon]
+}
 
-	return record
+// SelectWeapon puts the weapon at index i of the player's weapons in their
+// hand.
+func (p *Player) SelectWeapon(i int) error {
+	if i < 0 || i >= len(p.Weapons()) {
+		return fmt.Errorf("no weapon %d", i+1)
+	}
+	p.ActiveWeapon = i
+	return nil
 }
 
-// UpdateHealth updates the player's health and handles death.
-func UpdateHealth(p *Player, delta int) {
-	p.Health += delta
-	if p.Health <= 0 {
-		p.Alive = false
-		SavePlayer(p) // Save the dead state
-		fmt.Println("You have died and must start over.")
-		ResetPlayer(p) // Reset for new game start
+// UnequipWeapon unequips the player's weapon.
+func (p *Player) UnequipWeapon() {
+	if weapons := p.Weapons(); len(weapons) > 0 {
+		p.dropWeapon(weapons[len(weapons)-1])
 	}
-	SavePlayer(p) // Save any changes to player data
+	// Save the player's data after equipping the weapon
+	if err := SavePlayer(p); err != nil {
+		fmt.Printf("failed to save player: %v", err)
+	}
+
 }
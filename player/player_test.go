@@ -0,0 +1,34 @@
+package player
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockPlayer(t *testing.T) {
+	dir := useStore(t)
+	lock, err := LockPlayer("Night Owl", 1)
+	if err != nil {
+		t.Fatalf("LockPlayer: %v", err)
+	}
+
+	// The lock is kept with the store, not in the default data directory
+	if _, err := os.Stat(filepath.Join(dir, "u-"+PlayerID("Night Owl")+".json.lock")); err != nil {
+		t.Errorf("no lock file in the store: %v", err)
+	}
+
+	_, err = LockPlayer("Night Owl", 2)
+	var inUse *InUseError
+	if !errors.As(err, &inUse) || inUse.Node != 1 {
+		t.Fatalf("second LockPlayer = %v, want in use on node 1", err)
+	}
+
+	lock.Unlock()
+	again, err := LockPlayer("Night Owl", 2)
+	if err != nil {
+		t.Fatalf("LockPlayer after Unlock: %v", err)
+	}
+	again.Unlock()
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package safefile
+
+import "os"
+
+// flock does nothing where flock is not available; the lock file is still
+// written so the owner can be reported.
+func flock(f *os.File, wait bool) error {
+	return nil
+}
+
+// syncDir does nothing where directories cannot be flushed.
+func syncDir(dir string) error {
+	return nil
+}
@@ -18,6 +18,6 @@ echo "1" >> data/door32.sys
 
 # Start SpaceJunk3000
 echo "Starting SpaceJunk3000 with dummy door32.sys file..."
-bin/spacejunk3000 -door32 data/
+bin/spacejunk3000 -dropfile data/
 
 
@@ -0,0 +1,395 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"spacejunk3000/safefile"
+	"sync"
+)
+
+// DB is a Store kept in a single file, for boards with more players and
+// scores than are comfortable as one file each. The file is a log of JSON
+// records, one per line, that is replayed into memory when opened. Every
+// change appends a record, so adding a score costs the same however large
+// the leaderboard grows. Nodes share the file: each call takes a lock on it
+// and first reads whatever other nodes have appended.
+type DB struct {
+	path string
+
+	mu     sync.Mutex
+	f      *os.File
+	offset int64 // how much of the file has been read into memory
+	dead   int   // records that have been overwritten or deleted
+
+	docs   map[string]map[string][]byte // kind, then key
+	scores map[string][]Score
+	events []Event
+}
+
+// Kinds of document kept in a DB.
+const (
+	dbPlayers = "players"
+	dbBackups = "backups"
+	dbIndex   = "index"
+	dbRuns    = "runs"
+)
+
+// dbRecord is one line of a DB file.
+type dbRecord struct {
+	Op    string `json:"op"` // put, delete, score or event
+	Kind  string `json:"kind,omitempty"`
+	Key   string `json:"key,omitempty"`
+	Data  []byte `json:"data,omitempty"`
+	Score *Score `json:"score,omitempty"`
+	Event *Event `json:"event,omitempty"`
+}
+
+// Compact the file when opened once this many records are dead.
+const dbCompactAfter = 1000
+
+// OpenDB opens the single-file store at path, creating it if needed.
+func OpenDB(path string) (*DB, error) {
+	db := &DB{path: path}
+
+	lock, err := safefile.WaitLock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if err := db.reload(); err != nil {
+		return nil, err
+	}
+	if db.dead > dbCompactAfter {
+		if err := db.compact(); err != nil {
+			db.f.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// reload opens the file afresh and replays it from the start.
+func (db *DB) reload() error {
+	if db.f != nil {
+		db.f.Close()
+	}
+	f, err := os.OpenFile(db.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	db.f = f
+	db.offset = 0
+	db.dead = 0
+	db.docs = make(map[string]map[string][]byte)
+	db.scores = make(map[string][]Score)
+	db.events = nil
+	return db.catchUp()
+}
+
+// catchUp applies records appended to the file since it was last read.
+func (db *DB) catchUp() error {
+	// Another node may have compacted the file into a new one
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return err
+	}
+	open, err := db.f.Stat()
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(info, open) {
+		return db.reload()
+	}
+
+	if _, err := db.f.Seek(db.offset, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(db.f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Leave a line cut short by a crash to be overwritten
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		db.offset += int64(len(line))
+
+		var rec dbRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("error reading %s at offset %d: %v", db.path, db.offset-int64(len(line)), err)
+		}
+		db.apply(rec)
+	}
+}
+
+// apply replays a record into memory.
+func (db *DB) apply(rec dbRecord) {
+	switch rec.Op {
+	case "put":
+		if db.docs[rec.Kind] == nil {
+			db.docs[rec.Kind] = make(map[string][]byte)
+		}
+		if _, ok := db.docs[rec.Kind][rec.Key]; ok {
+			db.dead++
+		}
+		db.docs[rec.Kind][rec.Key] = rec.Data
+	case "delete":
+		if _, ok := db.docs[rec.Kind][rec.Key]; ok {
+			delete(db.docs[rec.Kind], rec.Key)
+			db.dead++
+		}
+		db.dead++ // the delete record itself
+	case "score":
+		if rec.Score != nil {
+			db.scores[rec.Key] = append(db.scores[rec.Key], *rec.Score)
+		}
+	case "event":
+		if rec.Event != nil {
+			db.events = append(db.events, *rec.Event)
+		}
+	}
+}
+
+// compact rewrites the file with only the records still in use.
+func (db *DB) compact() error {
+	var recs []dbRecord
+	for kind, docs := range db.docs {
+		for key, data := range docs {
+			recs = append(recs, dbRecord{Op: "put", Kind: kind, Key: key, Data: data})
+		}
+	}
+	for board, scores := range db.scores {
+		for i := range scores {
+			recs = append(recs, dbRecord{Op: "score", Key: board, Score: &scores[i]})
+		}
+	}
+	for i := range db.events {
+		recs = append(recs, dbRecord{Op: "event", Event: &db.events[i]})
+	}
+
+	var data []byte
+	for _, rec := range recs {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("error compacting %s: %v", db.path, err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := safefile.WriteFile(db.path, data, 0644); err != nil {
+		return fmt.Errorf("error compacting %s: %v", db.path, err)
+	}
+	return db.reload()
+}
+
+// view runs fn on an up to date copy of the file's contents.
+func (db *DB) view(fn func()) error {
+	return db.update(nil, fn)
+}
+
+// update appends rec to the file, if it is not nil, and then runs fn. Both
+// happen while holding the lock, after catching up with other nodes.
+func (db *DB) update(rec *dbRecord, fn func()) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.f == nil {
+		return fmt.Errorf("%s is closed", db.path)
+	}
+
+	lock, err := safefile.WaitLock(db.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := db.catchUp(); err != nil {
+		return err
+	}
+
+	if rec != nil {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("error marshaling record: %v", err)
+		}
+		line = append(line, '\n')
+
+		// Drop any line cut short by a crash before appending
+		if err := db.f.Truncate(db.offset); err != nil {
+			return err
+		}
+		if _, err := db.f.WriteAt(line, db.offset); err != nil {
+			return err
+		}
+		if err := db.f.Sync(); err != nil {
+			return err
+		}
+		db.offset += int64(len(line))
+		db.apply(*rec)
+	}
+
+	if fn != nil {
+		fn()
+	}
+	return nil
+}
+
+// get returns a document, or ErrNotFound.
+func (db *DB) get(kind, key string) ([]byte, error) {
+	var data []byte
+	var ok bool
+	err := db.view(func() {
+		data, ok = db.docs[kind][key]
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// put stores a document.
+func (db *DB) put(kind, key string, data []byte) error {
+	return db.update(&dbRecord{Op: "put", Kind: kind, Key: key, Data: data}, nil)
+}
+
+// remove deletes a document, reporting whether it existed.
+func (db *DB) remove(kind, key string) (bool, error) {
+	var found bool
+	err := db.view(func() {
+		_, found = db.docs[kind][key]
+	})
+	if err != nil || !found {
+		return false, err
+	}
+	return true, db.update(&dbRecord{Op: "delete", Kind: kind, Key: key}, nil)
+}
+
+// keys returns the keys of every document of a kind, sorted.
+func (db *DB) keys(kind string) ([]string, error) {
+	var keys []string
+	err := db.view(func() {
+		for key := range db.docs[kind] {
+			keys = append(keys, key)
+		}
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+func (db *DB) LoadPlayer(id string) ([]byte, error) {
+	return db.get(dbPlayers, id)
+}
+
+func (db *DB) SavePlayer(id string, data []byte) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	return db.put(dbPlayers, id, data)
+}
+
+func (db *DB) DeletePlayer(id string) error {
+	found, err := db.remove(dbPlayers, id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (db *DB) Players() ([]string, error) {
+	return db.keys(dbPlayers)
+}
+
+// LockPath returns a path beside the DB file, so the lock is seen by every
+// node sharing it.
+func (db *DB) LockPath(id string) string {
+	return fmt.Sprintf("%s.u-%s", db.path, id)
+}
+
+func (db *DB) BackupPlayer(id string, version int, data []byte) error {
+	return db.put(dbBackups, fmt.Sprintf("%s.v%d", id, version), data)
+}
+
+func (db *DB) LoadIndex() ([]byte, error) {
+	return db.get(dbIndex, "")
+}
+
+func (db *DB) SaveIndex(data []byte) error {
+	return db.put(dbIndex, "", data)
+}
+
+func (db *DB) LoadRun(id string) ([]byte, error) {
+	return db.get(dbRuns, id)
+}
+
+func (db *DB) SaveRun(id string, data []byte) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	return db.put(dbRuns, id, data)
+}
+
+func (db *DB) DeleteRun(id string) error {
+	_, err := db.remove(dbRuns, id)
+	return err
+}
+
+func (db *DB) Runs() ([]string, error) {
+	return db.keys(dbRuns)
+}
+
+func (db *DB) AddScore(board string, s Score) error {
+	return db.update(&dbRecord{Op: "score", Key: board, Score: &s}, nil)
+}
+
+func (db *DB) Scores(board string) ([]Score, error) {
+	var scores []Score
+	err := db.view(func() {
+		scores = append(scores, db.scores[board]...)
+	})
+	sortScores(scores)
+	return scores, err
+}
+
+func (db *DB) Boards() ([]string, error) {
+	var boards []string
+	err := db.view(func() {
+		for board := range db.scores {
+			boards = append(boards, board)
+		}
+	})
+	sort.Strings(boards)
+	return boards, err
+}
+
+func (db *DB) AppendEvent(e Event) error {
+	return db.update(&dbRecord{Op: "event", Event: &e}, nil)
+}
+
+func (db *DB) Events() ([]Event, error) {
+	var events []Event
+	err := db.view(func() {
+		events = append(events, db.events...)
+	})
+	return events, err
+}
+
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.f == nil {
+		return nil
+	}
+	err := db.f.Close()
+	db.f = nil
+	return err
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// openDB opens a DB in a temporary directory, closing it when the test ends.
+func openDB(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := OpenDB(path)
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// checkPlayer checks the saved player with the given ID.
+func checkPlayer(t *testing.T, s Store, id, want string) {
+	t.Helper()
+	data, err := s.LoadPlayer(id)
+	if err != nil {
+		t.Fatalf("LoadPlayer(%q): %v", id, err)
+	}
+	if string(data) != want {
+		t.Errorf("LoadPlayer(%q) = %s, want %s", id, data, want)
+	}
+}
+
+func TestDBReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spacejunk.db")
+	db := openDB(t, path)
+	fill(t, db)
+	if err := db.SavePlayer("ace-1", []byte(`{"name":"Ace","health":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeletePlayer("zed-3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteRun("ace-1"); err != nil {
+		t.Fatal(err)
+	}
+	want := dump(t, db)
+	db.Close()
+
+	if _, err := db.LoadPlayer("ace-1"); err == nil {
+		t.Error("LoadPlayer on a closed DB succeeded")
+	}
+
+	reopened := openDB(t, path)
+	if got := dump(t, reopened); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %+v, want %+v", got, want)
+	}
+	checkPlayer(t, reopened, "ace-1", `{"name":"Ace","health":3}`)
+	if _, err := reopened.LoadPlayer("zed-3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted player: %v, want ErrNotFound", err)
+	}
+	if _, err := reopened.LoadRun("ace-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted run: %v, want ErrNotFound", err)
+	}
+	if err := reopened.DeletePlayer("zed-3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeletePlayer of a deleted player = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDBTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spacejunk.db")
+	db := openDB(t, path)
+	if err := db.SavePlayer("ace-1", []byte(`{"name":"Ace"}`)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// A crash part way through appending a record
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"put","kind":"players","key":"bea-2","da`)
+	f.Close()
+
+	db = openDB(t, path)
+	checkPlayer(t, db, "ace-1", `{"name":"Ace"}`)
+	if _, err := db.LoadPlayer("bea-2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LoadPlayer of the cut short record = %v, want ErrNotFound", err)
+	}
+
+	// The next record replaces the one cut short
+	if err := db.SavePlayer("zed-3", []byte(`{"name":"Zed"}`)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	db = openDB(t, path)
+	checkPlayer(t, db, "ace-1", `{"name":"Ace"}`)
+	checkPlayer(t, db, "zed-3", `{"name":"Zed"}`)
+	if players, _ := db.Players(); !reflect.DeepEqual(players, []string{"ace-1", "zed-3"}) {
+		t.Errorf("Players = %q", players)
+	}
+}
+
+func TestDBCompactWhileOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spacejunk.db")
+	first := openDB(t, path)
+	for i := 0; i <= dbCompactAfter+1; i++ {
+		if err := first.SavePlayer("ace-1", []byte(`{"name":"Ace"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := first.AddScore("alltime", Score{PlayerID: "ace-1", Name: "Ace", Points: 5}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening a second handle compacts the file under the first
+	second := openDB(t, path)
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Fatalf("file is %d bytes after compacting, was %d", after.Size(), before.Size())
+	}
+
+	// Each handle sees what the other writes to the new file
+	if err := first.SavePlayer("bea-2", []byte(`{"name":"Bea"}`)); err != nil {
+		t.Fatal(err)
+	}
+	checkPlayer(t, second, "bea-2", `{"name":"Bea"}`)
+	if err := second.AddScore("alltime", Score{PlayerID: "bea-2", Name: "Bea", Points: 9}); err != nil {
+		t.Fatal(err)
+	}
+	scores, err := first.Scores("alltime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 2 || scores[0].Name != "Bea" || scores[1].Name != "Ace" {
+		t.Errorf("Scores = %+v, want Bea then Ace", scores)
+	}
+	checkPlayer(t, first, "ace-1", `{"name":"Ace"}`)
+	if got, want := dump(t, first), dump(t, second); !reflect.DeepEqual(got, want) {
+		t.Errorf("handles differ: %+v and %+v", got, want)
+	}
+}
+
+func TestDBLockPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spacejunk.db")
+	db := openDB(t, path)
+	if got := db.LockPath("ace-1"); filepath.Dir(got) != filepath.Dir(path) {
+		t.Errorf("LockPath = %s, want it beside %s", got, path)
+	}
+	if db.LockPath("ace-1") == db.LockPath("bea-2") {
+		t.Error("two players share a lock")
+	}
+}
+
+// day returns a fixed time, so saved times compare equal once loaded.
+func day(n int) time.Time {
+	return time.Date(2024, time.March, n, 12, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,257 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"spacejunk3000/safefile"
+	"strings"
+)
+
+// Files is a Store kept as JSON files in a directory, one per player, run
+// and leaderboard, plus an append-only event log:
+//
+//	u-<id>.json             player
+//	u-<id>.json.v<N>.bak    player backup
+//	players.json            alias to ID index
+//	r-<id>.json             run in progress
+//	scores-<board>.json     leaderboard
+//	events.log              one JSON event per line
+type Files struct {
+	Dir string
+}
+
+// NewFiles returns a Files store for the given directory.
+func NewFiles(dir string) *Files {
+	return &Files{Dir: dir}
+}
+
+func (f *Files) path(format string, args ...interface{}) string {
+	return filepath.Join(f.Dir, fmt.Sprintf(format, args...))
+}
+
+// read returns the contents of a file, or ErrNotFound if it does not exist.
+func (f *Files) read(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// write replaces a file, creating the directory if needed.
+func (f *Files) write(name string, data []byte) error {
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+	return safefile.WriteFile(name, data, 0644)
+}
+
+// keys returns the keys of the files in the directory named prefix+key+suffix.
+func (f *Files) keys(prefix, suffix string) ([]string, error) {
+	entries, err := os.ReadDir(f.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		if key := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (f *Files) LoadPlayer(id string) ([]byte, error) {
+	if err := validKey(id); err != nil {
+		return nil, err
+	}
+	return f.read(f.path("u-%s.json", id))
+}
+
+func (f *Files) SavePlayer(id string, data []byte) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	return f.write(f.path("u-%s.json", id), data)
+}
+
+func (f *Files) DeletePlayer(id string) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	err := os.Remove(f.path("u-%s.json", id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (f *Files) Players() ([]string, error) {
+	return f.keys("u-", ".json")
+}
+
+func (f *Files) LockPath(id string) string {
+	return f.path("u-%s.json", id)
+}
+
+func (f *Files) BackupPlayer(id string, version int, data []byte) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	return f.write(f.path("u-%s.json.v%d.bak", id, version), data)
+}
+
+func (f *Files) LoadIndex() ([]byte, error) {
+	return f.read(f.path("players.json"))
+}
+
+func (f *Files) SaveIndex(data []byte) error {
+	return f.write(f.path("players.json"), data)
+}
+
+func (f *Files) LoadRun(id string) ([]byte, error) {
+	if err := validKey(id); err != nil {
+		return nil, err
+	}
+	return f.read(f.path("r-%s.json", id))
+}
+
+func (f *Files) SaveRun(id string, data []byte) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	return f.write(f.path("r-%s.json", id), data)
+}
+
+func (f *Files) DeleteRun(id string) error {
+	if err := validKey(id); err != nil {
+		return err
+	}
+	err := os.Remove(f.path("r-%s.json", id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (f *Files) Runs() ([]string, error) {
+	return f.keys("r-", ".json")
+}
+
+// AddScore rewrites the whole leaderboard file, so it is best kept for
+// boards of a few thousand scores. Use a DB for larger ones.
+func (f *Files) AddScore(board string, s Score) error {
+	if err := validKey(board); err != nil {
+		return err
+	}
+	name := f.path("scores-%s.json", board)
+
+	// Hold the board while it is rewritten so two nodes do not lose a score
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+	lock, err := safefile.WaitLock(name)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	scores, err := f.Scores(board)
+	if err != nil {
+		return err
+	}
+	scores = append(scores, s)
+	sortScores(scores)
+
+	data, err := json.MarshalIndent(scores, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling scores: %v", err)
+	}
+	return f.write(name, data)
+}
+
+func (f *Files) Scores(board string) ([]Score, error) {
+	if err := validKey(board); err != nil {
+		return nil, err
+	}
+	data, err := f.read(f.path("scores-%s.json", board))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []Score
+	if err := json.Unmarshal(data, &scores); err != nil {
+		return nil, fmt.Errorf("error unmarshaling scores: %v", err)
+	}
+	sortScores(scores)
+	return scores, nil
+}
+
+func (f *Files) Boards() ([]string, error) {
+	boards, err := f.keys("scores-", ".json")
+	sort.Strings(boards)
+	return boards, err
+}
+
+// AppendEvent writes the event as a single line, which the system appends
+// whole even with several nodes writing at once.
+func (f *Files) AppendEvent(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error marshaling event: %v", err)
+	}
+
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path("events.log"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *Files) Events() ([]Event, error) {
+	file, err := os.Open(f.path("events.log"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip a line cut short by a crash
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+func (f *Files) Close() error {
+	return nil
+}
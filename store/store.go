@@ -0,0 +1,226 @@
+// Package store keeps everything SpaceJunk3000 saves between sessions:
+// players, runs in progress, leaderboards and the event log. Players and runs
+// are stored as the bytes their packages encode, so a Store never needs to
+// know their formats.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a player, backup or run is not in the store.
+var ErrNotFound = errors.New("not found")
+
+// Score is one entry on a leaderboard.
+type Score struct {
+	PlayerID string    `json:"player_id"`
+	Name     string    `json:"name"`
+	Points   int       `json:"points"`
+	Time     time.Time `json:"time"`
+}
+
+// Event is something that happened in a game, such as a player dying.
+type Event struct {
+	Time     time.Time `json:"time"`
+	PlayerID string    `json:"player_id"`
+	Type     string    `json:"type"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+// Store is where the game's saved data lives. Keys are player IDs, which are
+// safe to use as file names. Methods that load something return ErrNotFound
+// if it has not been saved.
+type Store interface {
+	// LoadPlayer returns the saved player with the given ID.
+	LoadPlayer(id string) ([]byte, error)
+	// SavePlayer replaces the saved player with the given ID.
+	SavePlayer(id string, data []byte) error
+	// DeletePlayer removes the saved player with the given ID.
+	DeletePlayer(id string) error
+	// Players returns the IDs of all saved players.
+	Players() ([]string, error)
+
+	// LockPath returns the file a player's session lock is taken on, beside
+	// the store's own files so every node using the store sees it.
+	LockPath(id string) string
+
+	// BackupPlayer keeps a copy of a player's save as it was at a given
+	// save format version.
+	BackupPlayer(id string, version int, data []byte) error
+
+	// LoadIndex returns the saved lookup from player alias to ID.
+	LoadIndex() ([]byte, error)
+	// SaveIndex replaces the lookup from player alias to ID.
+	SaveIndex(data []byte) error
+
+	// LoadRun returns the run in progress of the player with the given ID.
+	LoadRun(id string) ([]byte, error)
+	// SaveRun replaces the run in progress of the player with the given ID.
+	SaveRun(id string, data []byte) error
+	// DeleteRun removes the player's run. It is not an error if there is none.
+	DeleteRun(id string) error
+	// Runs returns the IDs of all players with a run in progress.
+	Runs() ([]string, error)
+
+	// AddScore records a score on the named leaderboard.
+	AddScore(board string, s Score) error
+	// Scores returns the named leaderboard, highest score first.
+	Scores(board string) ([]Score, error)
+	// Boards returns the names of all leaderboards.
+	Boards() ([]string, error)
+
+	// AppendEvent adds an event to the end of the event log.
+	AppendEvent(e Event) error
+	// Events returns the event log, oldest first.
+	Events() ([]Event, error)
+
+	// Close releases anything the store holds open.
+	Close() error
+}
+
+var defaultStore Store = NewFiles("data")
+
+// Default returns the store the game saves to, the data directory unless
+// SetDefault has been called.
+func Default() Store {
+	return defaultStore
+}
+
+// SetDefault changes the store the game saves to.
+func SetDefault(s Store) {
+	defaultStore = s
+}
+
+// Open returns the store described by spec: "files:DIR" for a directory of
+// JSON files, "db:FILE" for a single-file store, or a bare directory.
+func Open(spec string) (Store, error) {
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok {
+		kind, path = "files", spec
+	}
+	if path == "" {
+		return nil, fmt.Errorf("store %q has no path", spec)
+	}
+
+	switch kind {
+	case "files":
+		return NewFiles(path), nil
+	case "db":
+		return OpenDB(path)
+	default:
+		return nil, fmt.Errorf("unknown store type %q", kind)
+	}
+}
+
+// Copy copies everything in src to dst, e.g. to move a board from JSON files
+// to a single-file store. Backups are not copied. Scores and events are
+// added rather than replaced, so dst must be empty: copying twice would
+// otherwise record them twice.
+func Copy(dst, src Store) error {
+	if ok, err := isEmpty(dst); err != nil {
+		return fmt.Errorf("error checking destination store: %v", err)
+	} else if !ok {
+		return fmt.Errorf("destination store is not empty")
+	}
+
+	index, err := src.LoadIndex()
+	if err == nil {
+		err = dst.SaveIndex(index)
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("error copying player index: %v", err)
+	}
+
+	players, err := src.Players()
+	if err != nil {
+		return err
+	}
+	for _, id := range players {
+		data, err := src.LoadPlayer(id)
+		if err == nil {
+			err = dst.SavePlayer(id, data)
+		}
+		if err != nil {
+			return fmt.Errorf("error copying player %s: %v", id, err)
+		}
+	}
+
+	runs, err := src.Runs()
+	if err != nil {
+		return err
+	}
+	for _, id := range runs {
+		data, err := src.LoadRun(id)
+		if err == nil {
+			err = dst.SaveRun(id, data)
+		}
+		if err != nil {
+			return fmt.Errorf("error copying run %s: %v", id, err)
+		}
+	}
+
+	boards, err := src.Boards()
+	if err != nil {
+		return err
+	}
+	for _, board := range boards {
+		scores, err := src.Scores(board)
+		if err != nil {
+			return err
+		}
+		for _, s := range scores {
+			if err := dst.AddScore(board, s); err != nil {
+				return fmt.Errorf("error copying %s scores: %v", board, err)
+			}
+		}
+	}
+
+	events, err := src.Events()
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := dst.AppendEvent(e); err != nil {
+			return fmt.Errorf("error copying events: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// isEmpty reports whether a store holds nothing Copy would copy.
+func isEmpty(s Store) (bool, error) {
+	if _, err := s.LoadIndex(); !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	for _, list := range []func() ([]string, error){s.Players, s.Runs, s.Boards} {
+		keys, err := list()
+		if err != nil || len(keys) > 0 {
+			return false, err
+		}
+	}
+	events, err := s.Events()
+	return len(events) == 0, err
+}
+
+// validKey reports whether a key can safely be used as part of a file name.
+func validKey(key string) error {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\:`) {
+		return fmt.Errorf("invalid store key %q", key)
+	}
+	return nil
+}
+
+// sortScores orders a leaderboard highest score first, earliest first for ties.
+func sortScores(scores []Score) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Points != scores[j].Points {
+			return scores[i].Points > scores[j].Points
+		}
+		return scores[i].Time.Before(scores[j].Time)
+	})
+}
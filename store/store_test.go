@@ -0,0 +1,162 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// contents is everything Copy copies out of a store.
+type contents struct {
+	Index   string
+	Players map[string]string
+	Runs    map[string]string
+	Scores  map[string][]Score
+	Events  []Event
+}
+
+// fill saves a little of everything a store keeps.
+func fill(t *testing.T, s Store) {
+	t.Helper()
+	steps := []error{
+		s.SaveIndex([]byte(`{"ace":"ace-1","zed":"zed-3"}`)),
+		s.SavePlayer("ace-1", []byte(`{"name":"Ace"}`)),
+		s.SavePlayer("zed-3", []byte(`{"name":"Zed"}`)),
+		s.BackupPlayer("ace-1", 2, []byte(`{"version":2}`)),
+		s.SaveRun("ace-1", []byte(`{"defeated":2}`)),
+		s.SaveRun("zed-3", []byte(`{"defeated":0}`)),
+		s.AddScore("alltime", Score{PlayerID: "ace-1", Name: "Ace", Points: 40, Time: day(1)}),
+		s.AddScore("alltime", Score{PlayerID: "zed-3", Name: "Zed", Points: 70, Time: day(2)}),
+		s.AddScore("weekly", Score{PlayerID: "zed-3", Name: "Zed", Points: 10, Time: day(3)}),
+		s.AppendEvent(Event{Time: day(1), PlayerID: "ace-1", Type: "death", Detail: "Security Drone"}),
+		s.AppendEvent(Event{Time: day(2), PlayerID: "zed-3", Type: "victory"}),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+}
+
+// dump reads everything Copy copies out of a store.
+func dump(t *testing.T, s Store) contents {
+	t.Helper()
+	c := contents{Players: map[string]string{}, Runs: map[string]string{}, Scores: map[string][]Score{}}
+	if index, err := s.LoadIndex(); err == nil {
+		c.Index = string(index)
+	}
+
+	players, err := s.Players()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range players {
+		data, err := s.LoadPlayer(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Players[id] = string(data)
+	}
+	runs, err := s.Runs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range runs {
+		data, err := s.LoadRun(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Runs[id] = string(data)
+	}
+	boards, err := s.Boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, board := range boards {
+		scores, err := s.Scores(board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Scores[board] = scores
+	}
+	if c.Events, err = s.Events(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCopy(t *testing.T) {
+	dir := t.TempDir()
+	files := NewFiles(filepath.Join(dir, "data"))
+	fill(t, files)
+	want := dump(t, files)
+	if len(want.Players) != 2 || len(want.Runs) != 2 || len(want.Scores["alltime"]) != 2 || len(want.Events) != 2 {
+		t.Fatalf("filled store holds %+v", want)
+	}
+
+	db := openDB(t, filepath.Join(dir, "spacejunk.db"))
+	if err := Copy(db, files); err != nil {
+		t.Fatalf("Copy to DB: %v", err)
+	}
+	if got := dump(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("DB holds %+v, want %+v", got, want)
+	}
+
+	back := NewFiles(filepath.Join(dir, "back"))
+	if err := Copy(back, db); err != nil {
+		t.Fatalf("Copy to files: %v", err)
+	}
+	if got := dump(t, back); !reflect.DeepEqual(got, want) {
+		t.Errorf("files hold %+v, want %+v", got, want)
+	}
+
+	// Backups stay behind
+	if _, err := db.LoadPlayer("ace-1.v2"); err == nil {
+		t.Error("backup copied as a player")
+	}
+}
+
+func TestCopyNotEmpty(t *testing.T) {
+	dir := t.TempDir()
+	src := NewFiles(filepath.Join(dir, "data"))
+	fill(t, src)
+	db := openDB(t, filepath.Join(dir, "spacejunk.db"))
+	if err := Copy(db, src); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	want := dump(t, db)
+
+	// Copying again must not add every score and event a second time
+	err := Copy(db, src)
+	if err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Fatalf("second Copy = %v, want a not empty error", err)
+	}
+	if got := dump(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("second Copy changed the store to %+v", got)
+	}
+
+	// Any one kind of data makes a store not empty
+	for name, add := range map[string]func(s Store) error{
+		"index":  func(s Store) error { return s.SaveIndex([]byte(`{}`)) },
+		"player": func(s Store) error { return s.SavePlayer("ace-1", []byte(`{}`)) },
+		"run":    func(s Store) error { return s.SaveRun("ace-1", []byte(`{}`)) },
+		"score":  func(s Store) error { return s.AddScore("alltime", Score{Points: 1}) },
+		"event":  func(s Store) error { return s.AppendEvent(Event{Type: "death"}) },
+	} {
+		dst := NewFiles(filepath.Join(dir, name))
+		if err := add(dst); err != nil {
+			t.Fatal(err)
+		}
+		if err := Copy(dst, src); err == nil {
+			t.Errorf("Copy to a store with a %s succeeded", name)
+		}
+	}
+}
+
+func TestFilesLockPath(t *testing.T) {
+	dir := t.TempDir()
+	if got, want := NewFiles(dir).LockPath("ace-1"), filepath.Join(dir, "u-ace-1.json"); got != want {
+		t.Errorf("LockPath = %s, want %s", got, want)
+	}
+}
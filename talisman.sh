@@ -3,4 +3,4 @@
 # Start SpaceJunk3000
 echo "Starting SpaceJunk3000"
 cd /home/a1pha/Github/SpaceJunk3000
-bin/spacejunk3000 -door32 /home/a1pha/bbs/temp/$1/
\ No newline at end of file
+bin/spacejunk3000 -dropfile /home/a1pha/bbs/temp/$1/
\ No newline at end of file
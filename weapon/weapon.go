@@ -4,30 +4,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"spacejunk3000/dropitem"
+	"strconv"
+	"strings"
 )
 
 // Weapon represents the characteristics of a game weapon.
 type Weapon struct {
-	Name           string `json:"name"`
+	WeaponName     string `json:"name"`
 	WeaponTypeName string `json:"type"` // Rename the field to avoid conflict
 	AmmoType       string `json:"ammo_type,omitempty"`
 	AmmoCapacity   int    `json:"ammo_capacity,omitempty"`
 	FireRate       int    `json:"fire_rate,omitempty"`
 	Jammed         bool   `json:"jammed,omitempty"`
-	Slots          int    `json:"slots"`
+	SlotCount      int    `json:"slots"`
 	Ammo           int    `json:"ammo,omitempty"`
+	Reliability    int    `json:"reliability,omitempty"` // 1-6, higher jams less often; 0 for DefaultReliability
 }
 
+// DefaultReliability is the reliability of a weapon that does not give one.
+// A jam roll above a weapon's reliability jams it, so only 6 never jams.
+const DefaultReliability = 4
+
 // NewWeapon creates a new weapon with the given attributes.
 func NewWeapon(name, weaponTypeName, ammoType string, ammoCapacity int, fireRate int, jammed bool, slots, ammo int) *Weapon {
 	return &Weapon{
-		Name:           name,
+		WeaponName:     name,
 		WeaponTypeName: weaponTypeName, // Update the field name here
 		AmmoType:       ammoType,
 		AmmoCapacity:   ammoCapacity,
 		FireRate:       fireRate,
 		Jammed:         jammed,
-		Slots:          slots,
+		SlotCount:      slots,
 		Ammo:           ammo,
 	}
 }
@@ -52,7 +60,41 @@ func SaveWeapons(filename string, weapons []Weapon) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filename, bytes, os.ModePerm)
+	return os.WriteFile(filename, bytes, 0644)
+}
+
+// Find returns the weapon with the given name, or false if there is none.
+func Find(weapons []Weapon, name string) (Weapon, bool) {
+	for _, w := range weapons {
+		if w.WeaponName == name {
+			return w, true
+		}
+	}
+	return Weapon{}, false
+}
+
+// Refresh updates the weapon's stats from its entry in the catalog, keeping
+// the rounds it holds and whether it is jammed. Saves keep a weapon as it
+// was when found, so this picks up later changes to its definition. It
+// reports whether the weapon is in the catalog.
+func (w *Weapon) Refresh(catalog []Weapon) bool {
+	c, ok := Find(catalog, w.WeaponName)
+	if !ok {
+		return false
+	}
+	c.Ammo = min(w.Ammo, c.AmmoCapacity)
+	c.Jammed = w.Jammed
+	*w = c
+	return true
+}
+
+// EffectiveReliability returns the weapon's reliability, or
+// DefaultReliability if it does not give one.
+func (w *Weapon) EffectiveReliability() int {
+	if w.Reliability <= 0 {
+		return DefaultReliability
+	}
+	return w.Reliability
 }
 
 // WeaponType returns the type of the weapon.
@@ -60,6 +102,65 @@ func (w *Weapon) WeaponType() string {
 	return w.WeaponTypeName // Access the field directly
 }
 
+// Name returns the name of the weapon.
+func (w *Weapon) Name() string {
+	return w.WeaponName
+}
+
+// Slots returns the inventory slots the weapon takes up.
+func (w *Weapon) Slots() int {
+	return w.SlotCount
+}
+
+// Kind returns dropitem.KindWeapon.
+func (w *Weapon) Kind() string {
+	return dropitem.KindWeapon
+}
+
+// Describe returns a one-line summary of the weapon.
+func (w *Weapon) Describe() string {
+	desc := fmt.Sprintf("%s (%s), %d slot(s)", w.WeaponName, strings.ToLower(w.WeaponTypeName), w.SlotCount)
+	if w.AmmoType != "" {
+		desc += fmt.Sprintf(", %d/%d %s rounds, fires %d", w.Ammo, w.AmmoCapacity, strings.ToLower(w.AmmoType), w.FireRate)
+	}
+	if w.Jammed {
+		desc += ", jammed"
+	}
+	return desc
+}
+
+// Stats returns the weapon's details. Only ranged weapons have ammunition
+// and a fire rate.
+func (w *Weapon) Stats() []dropitem.Stat {
+	stats := []dropitem.Stat{dropitem.TextStat("Type", w.WeaponTypeName), dropitem.NumStat("Weight", w.SlotCount, false)}
+	if w.WeaponTypeName == "Ranged" {
+		stats = append(stats,
+			dropitem.TextStat("Ammo", fmt.Sprintf("%d/%d %s", w.Ammo, w.AmmoCapacity, w.AmmoType)),
+			dropitem.NumStat("Capacity", w.AmmoCapacity, true),
+			dropitem.NumStat("Fire rate", w.FireRate, true),
+		)
+		stats = append(stats, dropitem.NumStat("Reliability", w.EffectiveReliability(), true))
+	}
+	if w.Jammed {
+		stats = append(stats, dropitem.TextStat("Status", "JAMMED"))
+	}
+	return stats
+}
+
+// Row returns the weapon's columns in the inventory list. A jammed weapon
+// shows JAM in place of its fire rate.
+func (w *Weapon) Row() dropitem.Row {
+	if w.Jammed {
+		return dropitem.Row{Type: w.WeaponTypeName, Ammo: strconv.Itoa(w.Ammo), Fire: "JAM", Alert: true}
+	}
+	return dropitem.Row{Type: w.WeaponTypeName, Ammo: strconv.Itoa(w.Ammo), Fire: strconv.Itoa(w.FireRate)}
+}
+
+// Use takes the weapon in hand.
+func (w *Weapon) Use(u dropitem.User) bool {
+	return u.Wield(w)
+}
+
 func (g *Weapon) String() string {
-	return fmt.Sprintf("Weapon: %s, Type: %s", g.Name, g.WeaponTypeName)
+	return fmt.Sprintf("Weapon: %s, Type: %s", g.WeaponName, g.WeaponTypeName)
 }
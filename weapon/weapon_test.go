@@ -0,0 +1,39 @@
+package weapon
+
+import "testing"
+
+func TestRefresh(t *testing.T) {
+	catalog := []Weapon{
+		{WeaponName: "Hand Cannon", WeaponTypeName: "Ranged", AmmoType: "Ballistic", AmmoCapacity: 3, FireRate: 1, Reliability: 3, SlotCount: 2},
+		{WeaponName: "Alien Blade", WeaponTypeName: "Blade", SlotCount: 2},
+	}
+
+	// A weapon saved before the catalog gave reliability
+	saved := Weapon{WeaponName: "Hand Cannon", WeaponTypeName: "Ranged", AmmoType: "Ballistic", AmmoCapacity: 5, FireRate: 2, SlotCount: 2, Ammo: 4, Jammed: true}
+	if saved.EffectiveReliability() != DefaultReliability {
+		t.Errorf("EffectiveReliability = %d, want %d", saved.EffectiveReliability(), DefaultReliability)
+	}
+	if !saved.Refresh(catalog) {
+		t.Fatal("Refresh did not find the Hand Cannon")
+	}
+	if saved.Reliability != 3 || saved.EffectiveReliability() != 3 {
+		t.Errorf("Reliability = %d, want the catalog's 3", saved.Reliability)
+	}
+	if saved.AmmoCapacity != 3 || saved.FireRate != 1 {
+		t.Errorf("capacity %d and fire rate %d, want the catalog's 3 and 1", saved.AmmoCapacity, saved.FireRate)
+	}
+	if saved.Ammo != 3 || !saved.Jammed {
+		t.Errorf("Ammo = %d and Jammed = %v, want 3 and true", saved.Ammo, saved.Jammed)
+	}
+	if catalog[0].Ammo != 0 || catalog[0].Jammed {
+		t.Error("Refresh changed the catalog")
+	}
+
+	unknown := Weapon{WeaponName: "Prototype", Reliability: 6}
+	if unknown.Refresh(catalog) {
+		t.Error("Refresh found a weapon that is not in the catalog")
+	}
+	if unknown.Reliability != 6 {
+		t.Errorf("Reliability = %d, want it kept at 6", unknown.Reliability)
+	}
+}